@@ -0,0 +1,66 @@
+package httputil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID stored in ctx by RequestIDHandler, or
+// an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDHandler assigns an ID to each request, taken from its
+// X-Request-ID header if valid, and sets it on the response. The context of
+// the request carries the ID and a child of l with the fields request_id,
+// method and path for nlog.FromContext. If l is nil, the logger of the
+// request's context is used.
+func RequestIDHandler(h http.Handler, l nlog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := r.Context()
+		parent := l
+		if parent == nil {
+			parent = nlog.FromContext(ctx)
+		}
+		child := parent.With("request_id", id, "method", r.Method, "path", r.URL.Path)
+		ctx = context.WithValue(ctx, requestIDKey{}, id)
+		ctx = nlog.NewContext(ctx, child)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether id is short printable ASCII, so that
+// clients cannot inject arbitrary text into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,51 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+	"github.com/najeira/goutils/nlog/nlogtest"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	l := nlogtest.New(t)
+	var id string
+	h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r.Context())
+		nlog.FromContext(r.Context()).Info("handled")
+	}), l)
+
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if id != "abc-123" {
+		t.Errorf("%s != abc-123", id)
+	}
+	if r := rec.Header().Get(RequestIDHeader); r != id {
+		t.Errorf("%s != %s", r, id)
+	}
+	entries := l.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("%d != 1", len(entries))
+	}
+	for key, value := range map[string]string{"request_id": "abc-123", "method": "GET", "path": "/items"} {
+		if v, _ := entries[0].Field(key); v != value {
+			t.Errorf("%s: %v != %s", key, v, value)
+		}
+	}
+
+	// an invalid ID is replaced
+	req = httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if len(id) != 32 {
+		t.Errorf("%s is not a new ID", id)
+	}
+	if r := rec.Header().Get(RequestIDHeader); r != id {
+		t.Errorf("%s != %s", r, id)
+	}
+}
@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"math/big"
 	"strconv"
 )
 
 var (
 	ErrNotFound    = errors.New("not found")
 	ErrInvalidType = errors.New("invalid type")
+	ErrOverflow    = errors.New("overflow")
+	ErrTooDeep     = errors.New("too deep")
+	ErrTooLarge    = errors.New("too large")
 )
 
 func Version() string {
@@ -27,9 +31,12 @@ func (v *Value) MarshalJSON() ([]byte, error) {
 
 func (v *Value) UnmarshalJSON(b []byte) error {
 	d := json.NewDecoder(bytes.NewBuffer(b))
+	d.UseNumber()
 	return d.Decode(&v.Value)
 }
 
+// DecodeString decodes a JSON document. Numbers are decoded as json.Number
+// to keep their precision.
 func DecodeString(s string) (*Value, error) {
 	v := &Value{}
 	err := v.UnmarshalJSON([]byte(s))
@@ -39,19 +46,124 @@ func DecodeString(s string) (*Value, error) {
 	return v, nil
 }
 
+// DecodeReader decodes a JSON document from r as DecodeString does.
 func DecodeReader(r io.Reader) (*Value, error) {
-	d := json.NewDecoder(r)
 	v := &Value{}
-	err := d.Decode(&v.Value)
+	err := Decode(r, v, &DecodeConfig{UseNumber: true})
 	return v, err
 }
 
+type DecodeConfig struct {
+	// UseNumber decodes numbers into interface{} as json.Number instead of
+	// float64.
+	UseNumber bool
+
+	// DisallowUnknownFields fails decoding into a struct if the document
+	// has a member that does not match a field.
+	DisallowUnknownFields bool
+
+	// MaxDepth is the maximum nesting of arrays and objects, no limit if
+	// zero. The document is read into memory to check it before decoding.
+	MaxDepth int
+
+	// MaxSize is the maximum number of bytes read from r, no limit if zero.
+	MaxSize int64
+}
+
+// Decode decodes a JSON document from r into v. A nil config decodes as
+// json.Decoder does. A *Value is decoded with the config too.
+func Decode(r io.Reader, v interface{}, config *DecodeConfig) error {
+	if config == nil {
+		config = &DecodeConfig{}
+	}
+	if config.MaxSize > 0 {
+		r = &limitReader{r: r, n: config.MaxSize}
+	}
+	if config.MaxDepth > 0 {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r).Decode(&raw); err != nil {
+			return err
+		}
+		if err := checkDepth(raw, config.MaxDepth); err != nil {
+			return err
+		}
+		r = bytes.NewReader(raw)
+	}
+	d := json.NewDecoder(r)
+	if config.UseNumber {
+		d.UseNumber()
+	}
+	if config.DisallowUnknownFields {
+		d.DisallowUnknownFields()
+	}
+	if value, ok := v.(*Value); ok {
+		v = &value.Value
+	}
+	return d.Decode(v)
+}
+
+// limitReader reads up to n bytes and then fails with ErrTooLarge unless
+// the input ends.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 && err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// checkDepth fails with ErrTooDeep if arrays and objects in a valid JSON
+// document are nested deeper than limit.
+func checkDepth(b []byte, limit int) error {
+	depth := 0
+	str := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if str {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				str = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			str = true
+		case '[', '{':
+			if depth++; depth > limit {
+				return ErrTooDeep
+			}
+		case ']', '}':
+			depth--
+		}
+	}
+	return nil
+}
+
 type Map map[string]interface{}
 
 func (v Value) Map() (Map, error) {
 	switch d := v.Value.(type) {
 	case map[string]interface{}:
 		return d, nil
+	case Map:
+		return d, nil
+	case *Value:
+		return d.Map()
 	}
 	return nil, ErrInvalidType
 }
@@ -73,18 +185,19 @@ func (m Map) Get(name string) (*Value, bool) {
 }
 
 func (m Map) GetPath(name ...string) (*Value, bool) {
-	ret := m
+	ret := &Value{m}
 	for _, n := range name {
-		v, ok := ret.Get(n)
-		if !ok {
+		cur, err := ret.Map()
+		if err != nil || cur == nil {
 			return nil, false
 		}
-		ret, err := v.Map()
-		if err != nil || ret == nil {
+		v, ok := cur.Get(n)
+		if !ok {
 			return nil, false
 		}
+		ret = v
 	}
-	return &Value{ret}, true
+	return ret, true
 }
 
 func (m Map) Set(name string, value interface{}) {
@@ -101,6 +214,10 @@ func (v Value) Array() (Array, error) {
 	switch d := v.Value.(type) {
 	case []interface{}:
 		return d, nil
+	case Array:
+		return d, nil
+	case *Value:
+		return d.Array()
 	}
 	return nil, ErrInvalidType
 }
@@ -120,19 +237,33 @@ func (v Value) String() (string, error) {
 func (v Value) Int() (int64, error) {
 	switch d := v.Value.(type) {
 	case json.Number:
-		return d.Int64()
-	case int64:
-		return d, nil
+		if i, err := d.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := d.Float64()
+		return int64(f), err
 	case int:
+		return int64(d), nil
 	case int8:
+		return int64(d), nil
 	case int16:
+		return int64(d), nil
 	case int32:
+		return int64(d), nil
+	case int64:
+		return d, nil
 	case uint:
+		return int64(d), nil
 	case uint8:
+		return int64(d), nil
 	case uint16:
+		return int64(d), nil
 	case uint32:
+		return int64(d), nil
 	case uint64:
+		return int64(d), nil
 	case float32:
+		return int64(d), nil
 	case float64:
 		return int64(d), nil
 	case string:
@@ -145,33 +276,174 @@ func (v Value) Float() (float64, error) {
 	switch d := v.Value.(type) {
 	case json.Number:
 		return d.Float64()
-	case float64:
-		return d, nil
 	case int:
+		return float64(d), nil
 	case int8:
+		return float64(d), nil
 	case int16:
+		return float64(d), nil
 	case int32:
+		return float64(d), nil
 	case int64:
+		return float64(d), nil
 	case uint:
+		return float64(d), nil
 	case uint8:
+		return float64(d), nil
 	case uint16:
+		return float64(d), nil
 	case uint32:
+		return float64(d), nil
 	case uint64:
+		return float64(d), nil
 	case float32:
 		return float64(d), nil
+	case float64:
+		return d, nil
 	case string:
 		return strconv.ParseFloat(d, 64)
 	}
 	return 0, ErrInvalidType
 }
 
+// Int64 returns the value as an int64. Unlike Int, it fails with
+// ErrOverflow if the value is out of range and with ErrInvalidType if it
+// has a fraction.
+func (v Value) Int64() (int64, error) {
+	if d, ok := v.Value.(json.Number); ok {
+		if i, err := strconv.ParseInt(string(d), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	b, err := v.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !b.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return b.Int64(), nil
+}
+
+// Uint64 returns the value as a uint64 as Int64 does.
+func (v Value) Uint64() (uint64, error) {
+	if d, ok := v.Value.(json.Number); ok {
+		if i, err := strconv.ParseUint(string(d), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	b, err := v.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !b.IsUint64() {
+		return 0, ErrOverflow
+	}
+	return b.Uint64(), nil
+}
+
+// BigInt returns the value as an integer of any size. Numbers with an
+// exponent such as 1e30 are accepted, but ErrInvalidType is returned if the
+// value has a fraction.
+func (v Value) BigInt() (*big.Int, error) {
+	r, err := toRat(v.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, ErrInvalidType
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// BigFloat returns the value as a big.Float with enough precision to hold
+// an integer value exactly.
+func (v Value) BigFloat() (*big.Float, error) {
+	r, err := toRat(v.Value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(r), nil
+}
+
+// toRat returns a number or a string of a number as an exact rational.
+func toRat(v interface{}) (*big.Rat, error) {
+	var s string
+	switch d := v.(type) {
+	case json.Number:
+		s = string(d)
+	case string:
+		s = d
+	case int:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case int8:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case int16:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(d)), nil
+	case int64:
+		return new(big.Rat).SetInt64(d), nil
+	case uint:
+		return new(big.Rat).SetUint64(uint64(d)), nil
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(d)), nil
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(d)), nil
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(d)), nil
+	case uint64:
+		return new(big.Rat).SetUint64(d), nil
+	case float32:
+		return toRat(float64(d))
+	case float64:
+		if r := new(big.Rat).SetFloat64(d); r != nil {
+			return r, nil
+		}
+		return nil, ErrInvalidType
+	default:
+		return nil, ErrInvalidType
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	return r, nil
+}
+
 func (v Value) Bool() (bool, error) {
 	switch d := v.Value.(type) {
 	case bool:
 		return d, nil
-	case int, int8, int16, int32, int64:
-	case uint, uint8, uint16, uint32, uint64:
-	case float32, float64:
+	case json.Number:
+		f, _, err := big.ParseFloat(string(d), 10, 64, big.ToNearestEven)
+		if err != nil {
+			return false, ErrInvalidType
+		}
+		return f.Sign() != 0, nil
+	case int:
+		return d != 0, nil
+	case int8:
+		return d != 0, nil
+	case int16:
+		return d != 0, nil
+	case int32:
+		return d != 0, nil
+	case int64:
+		return d != 0, nil
+	case uint:
+		return d != 0, nil
+	case uint8:
+		return d != 0, nil
+	case uint16:
+		return d != 0, nil
+	case uint32:
+		return d != 0, nil
+	case uint64:
+		return d != 0, nil
+	case float32:
+		return d != 0, nil
+	case float64:
 		return d != 0, nil
 	case string:
 		return strconv.ParseBool(d)
@@ -1,6 +1,9 @@
 package jsonutil
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +96,194 @@ func TestUnmarshalJSON(t *testing.T) {
 		t.Error("invalid name")
 	}
 }
+
+func TestGetPath(t *testing.T) {
+	v, err := DecodeString(`{"a": {"b": {"c": 1}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc, ok := v.GetPath("a", "b", "c")
+	if !ok {
+		t.Fatal("a.b.c not found")
+	}
+	if i, _ := vc.Int(); i != 1 {
+		t.Error("invalid a.b.c")
+	}
+	if _, ok := v.GetPath("a", "x"); ok {
+		t.Error("a.x found")
+	}
+	if _, ok := v.GetPath("a", "b", "c", "d"); ok {
+		t.Error("a.b.c.d found")
+	}
+
+	m, _ := v.Map()
+	m.Set("s", map[string]interface{}{"t": "u"})
+	vu, ok := m.GetPath("s", "t")
+	if !ok {
+		t.Fatal("s.t not found")
+	}
+	if u, _ := vu.String(); u != "u" {
+		t.Error("invalid s.t")
+	}
+}
+
+func TestDecodeNumber(t *testing.T) {
+	v, err := DecodeString(`{"id": 9007199254740993}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := v.Pointer("/id")
+	if i, err := id.Int64(); err != nil || i != 9007199254740993 {
+		t.Errorf("%d != 9007199254740993: %v", i, err)
+	}
+	if s := marshalString(t, v); s != `{"id":9007199254740993}` {
+		t.Errorf("%s", s)
+	}
+
+	v, err = DecodeReader(strings.NewReader(`[18446744073709551615]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := v.Value.([]interface{})[0].(json.Number).Int64(); err == nil {
+		t.Errorf("%d", u)
+	}
+}
+
+func TestInt(t *testing.T) {
+	for _, n := range []interface{}{int(3), int8(3), int16(3), int32(3), int64(3), uint(3), uint8(3), uint16(3), uint32(3), uint64(3), float32(3.5), float64(3.5), json.Number("3"), json.Number("3.5"), "3"} {
+		v := Value{n}
+		if i, err := v.Int(); err != nil || i != 3 {
+			t.Errorf("%T: %d != 3: %v", n, i, err)
+		}
+		if f, err := v.Float(); err != nil || f < 3 || f > 3.5 {
+			t.Errorf("%T: %f: %v", n, f, err)
+		}
+	}
+}
+
+func TestBool(t *testing.T) {
+	v, err := DecodeString(`[0, 1, 0.0, -2.5, 1e-400, true, "false"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range []bool{false, true, false, true, true, true, false} {
+		item := Value{v.Value.([]interface{})[i]}
+		if b, err := item.Bool(); err != nil || b != e {
+			t.Errorf("%v: %t != %t: %v", item.Value, b, e, err)
+		}
+	}
+
+	for _, n := range []interface{}{int(0), int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0), uint16(0), uint32(0), uint64(0), float32(0), float64(0)} {
+		if b, err := (Value{n}).Bool(); err != nil || b {
+			t.Errorf("%T: %t: %v", n, b, err)
+		}
+	}
+	for _, n := range []interface{}{int(3), int8(3), int16(3), int32(3), int64(3), uint(3), uint8(3), uint16(3), uint32(3), uint64(3), float32(0.5), float64(0.5)} {
+		if b, err := (Value{n}).Bool(); err != nil || !b {
+			t.Errorf("%T: %t: %v", n, b, err)
+		}
+	}
+	if _, err := (Value{nil}).Bool(); err != ErrInvalidType {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestInt64(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		e   int64
+		err error
+	}{
+		{json.Number("-9223372036854775808"), -9223372036854775808, nil},
+		{json.Number("9223372036854775808"), 0, ErrOverflow},
+		{json.Number("1e3"), 1000, nil},
+		{json.Number("1e30"), 0, ErrOverflow},
+		{json.Number("1.5"), 0, ErrInvalidType},
+		{uint64(1 << 63), 0, ErrOverflow},
+		{float64(1 << 62), 1 << 62, nil},
+		{float64(1e19), 0, ErrOverflow},
+		{"12", 12, nil},
+		{true, 0, ErrInvalidType},
+	}
+	for _, test := range tests {
+		v := Value{test.v}
+		i, err := v.Int64()
+		if i != test.e || !errors.Is(err, test.err) {
+			t.Errorf("%v: %d != %d: %v", test.v, i, test.e, err)
+		}
+	}
+
+	if u, err := (Value{json.Number("18446744073709551615")}).Uint64(); err != nil || u != 18446744073709551615 {
+		t.Errorf("%d: %v", u, err)
+	}
+	if _, err := (Value{json.Number("-1")}).Uint64(); err != ErrOverflow {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	b, err := (Value{json.Number("123456789012345678901234567890")}).BigInt()
+	if err != nil || b.String() != "123456789012345678901234567890" {
+		t.Errorf("%s: %v", b, err)
+	}
+	b, err = (Value{json.Number("1.2e30")}).BigInt()
+	if err != nil || b.String() != "1200000000000000000000000000000" {
+		t.Errorf("%s: %v", b, err)
+	}
+
+	f, err := (Value{json.Number("123456789012345678901234567890")}).BigFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := f.Text('f', 0); s != "123456789012345678901234567890" {
+		t.Errorf("%s != 123456789012345678901234567890", s)
+	}
+	f, err = (Value{json.Number("0.25")}).BigFloat()
+	if err != nil || f.Text('g', 10) != "0.25" {
+		t.Errorf("%s: %v", f, err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type user struct {
+		ID int64 `json:"id"`
+	}
+	var u user
+	err := Decode(strings.NewReader(`{"id": 1, "name": "a"}`), &u, &DecodeConfig{DisallowUnknownFields: true})
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("%v", err)
+	}
+	if err := Decode(strings.NewReader(`{"id": 1, "name": "a"}`), &u, nil); err != nil || u.ID != 1 {
+		t.Errorf("%d: %v", u.ID, err)
+	}
+
+	var v Value
+	if err := Decode(strings.NewReader(`1`), &v, nil); err != nil {
+		t.Error(err)
+	} else if _, ok := v.Value.(float64); !ok {
+		t.Errorf("%T", v.Value)
+	}
+
+	tests := []struct {
+		s      string
+		config DecodeConfig
+		err    error
+	}{
+		{`[[["]]]]"]]]`, DecodeConfig{MaxDepth: 3}, nil},
+		{`[[[[1]]]]`, DecodeConfig{MaxDepth: 3}, ErrTooDeep},
+		{`{"a": {"b": {"c": "\"{"}}}`, DecodeConfig{MaxDepth: 3}, nil},
+		{`{"a": {"b": {"c": {}}}}`, DecodeConfig{MaxDepth: 3}, ErrTooDeep},
+		{`"abcd"`, DecodeConfig{MaxSize: 6}, nil},
+		{`"abcd" `, DecodeConfig{MaxSize: 6}, nil},
+		{`123`, DecodeConfig{MaxSize: 3}, nil},
+		{`"abcde"`, DecodeConfig{MaxSize: 6}, ErrTooLarge},
+		{`[[[[1]]]]`, DecodeConfig{MaxSize: 6, MaxDepth: 5}, ErrTooLarge},
+	}
+	for _, test := range tests {
+		var v Value
+		err := Decode(strings.NewReader(test.s), &v, &test.config)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %v != %v", test.s, err, test.err)
+		}
+	}
+}
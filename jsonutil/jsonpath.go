@@ -0,0 +1,1333 @@
+package jsonutil
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JSONPath is a compiled JSONPath query as defined by RFC 9535, such as
+// "$.items[?@.price < 10].name".
+type JSONPath struct {
+	expr string
+	segs []querySegment
+}
+
+// Match is a value selected by a JSONPath query with its normalized path,
+// such as "$['items'][0]".
+type Match struct {
+	Path  string
+	Value *Value
+}
+
+// QueryError reports an invalid JSONPath query.
+type QueryError struct {
+	Query  string
+	Offset int // byte offset in Query
+	Msg    string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("jsonutil: invalid JSONPath %q at offset %d: %s", e.Query, e.Offset, e.Msg)
+}
+
+// CompileJSONPath parses a JSONPath query.
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	p := &jpParser{s: expr}
+	if !p.consume("$") {
+		return nil, p.errorf("query must start with $")
+	}
+	segs, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return &JSONPath{expr: expr, segs: segs}, nil
+}
+
+// MustCompileJSONPath is like CompileJSONPath but panics if the query is
+// invalid.
+func MustCompileJSONPath(expr string) *JSONPath {
+	q, err := CompileJSONPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func (q *JSONPath) String() string {
+	return q.expr
+}
+
+// Select returns the values selected by the query in document order.
+// Object members are visited in the order of their keys.
+func (q *JSONPath) Select(v *Value) []Match {
+	root := unwrap(v.Value)
+	nodes := evalSegments(q.segs, root, []jpNode{{path: "$", value: root}})
+	matches := make([]Match, len(nodes))
+	for i, n := range nodes {
+		matches[i] = Match{Path: n.path, Value: &Value{n.value}}
+	}
+	return matches
+}
+
+// Query evaluates a JSONPath query against v.
+func (v *Value) Query(expr string) ([]Match, error) {
+	q, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Select(v), nil
+}
+
+type jpNode struct {
+	path  string
+	value interface{}
+}
+
+type querySegment struct {
+	descendant bool
+	selectors  []selector
+}
+
+type selector interface {
+	apply(root interface{}, n jpNode, out []jpNode) []jpNode
+}
+
+func evalSegments(segs []querySegment, root interface{}, nodes []jpNode) []jpNode {
+	for _, seg := range segs {
+		var out []jpNode
+		for _, n := range nodes {
+			out = seg.apply(root, n, out)
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+func (seg querySegment) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	for _, sel := range seg.selectors {
+		out = sel.apply(root, n, out)
+	}
+	if seg.descendant {
+		for _, c := range children(n) {
+			out = seg.apply(root, c, out)
+		}
+	}
+	return out
+}
+
+// children returns the elements of an array or the members of an object
+// in the order of their keys.
+func children(n jpNode) []jpNode {
+	switch d := n.value.(type) {
+	case []interface{}:
+		nodes := make([]jpNode, len(d))
+		for i, v := range d {
+			nodes[i] = jpNode{path: indexPath(n.path, i), value: unwrap(v)}
+		}
+		return nodes
+	case map[string]interface{}:
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		nodes := make([]jpNode, len(keys))
+		for i, k := range keys {
+			nodes[i] = jpNode{path: namePath(n.path, k), value: unwrap(d[k])}
+		}
+		return nodes
+	}
+	return nil
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// namePath appends a member name to a normalized path, escaping it as
+// RFC 9535 section 2.7 requires.
+func namePath(path string, name string) string {
+	var b strings.Builder
+	b.WriteString(path)
+	b.WriteString("['")
+	for _, r := range name {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteString("']")
+	return b.String()
+}
+
+type nameSelector string
+
+func (s nameSelector) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	if m, ok := n.value.(map[string]interface{}); ok {
+		if v, ok := m[string(s)]; ok {
+			out = append(out, jpNode{path: namePath(n.path, string(s)), value: unwrap(v)})
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	return append(out, children(n)...)
+}
+
+type indexSelector int
+
+func (s indexSelector) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	if a, ok := n.value.([]interface{}); ok {
+		i := int(s)
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			out = append(out, jpNode{path: indexPath(n.path, i), value: unwrap(a[i])})
+		}
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end, step *int
+}
+
+func (s sliceSelector) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	a, ok := n.value.([]interface{})
+	if !ok {
+		return out
+	}
+	step := 1
+	if s.step != nil {
+		step = *s.step
+	}
+	if step == 0 {
+		return out
+	}
+	size := len(a)
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + size
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		return min(max(i, lo), hi)
+	}
+	if step > 0 {
+		lower, upper := 0, size
+		if s.start != nil {
+			lower = clamp(normalize(*s.start), 0, size)
+		}
+		if s.end != nil {
+			upper = clamp(normalize(*s.end), 0, size)
+		}
+		for i := lower; i < upper; i += step {
+			out = append(out, jpNode{path: indexPath(n.path, i), value: unwrap(a[i])})
+		}
+		return out
+	}
+	upper, lower := size-1, -1
+	if s.start != nil {
+		upper = clamp(normalize(*s.start), -1, size-1)
+	}
+	if s.end != nil {
+		lower = clamp(normalize(*s.end), -1, size-1)
+	}
+	for i := upper; i > lower; i += step {
+		out = append(out, jpNode{path: indexPath(n.path, i), value: unwrap(a[i])})
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+func (s filterSelector) apply(root interface{}, n jpNode, out []jpNode) []jpNode {
+	for _, c := range children(n) {
+		if s.expr.test(root, c.value) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// logicalExpr is a filter expression.
+type logicalExpr interface {
+	test(root, current interface{}) bool
+}
+
+type orExpr []logicalExpr
+
+func (e orExpr) test(root, current interface{}) bool {
+	for _, x := range e {
+		if x.test(root, current) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (e andExpr) test(root, current interface{}) bool {
+	for _, x := range e {
+		if !x.test(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	x logicalExpr
+}
+
+func (e notExpr) test(root, current interface{}) bool {
+	return !e.x.test(root, current)
+}
+
+// existExpr tests whether a query selects any node.
+type existExpr struct {
+	q *filterQuery
+}
+
+func (e existExpr) test(root, current interface{}) bool {
+	return len(e.q.eval(root, current)) > 0
+}
+
+// funcTest tests the result of a function returning LogicalType or
+// NodesType.
+type funcTest struct {
+	fn *funcExpr
+}
+
+func (e funcTest) test(root, current interface{}) bool {
+	switch r := e.fn.call(root, current).(type) {
+	case bool:
+		return r
+	case []jpNode:
+		return len(r) > 0
+	}
+	return false
+}
+
+type compareExpr struct {
+	op          string
+	left, right *operand
+}
+
+func (e compareExpr) test(root, current interface{}) bool {
+	a, aok := e.left.value(root, current)
+	b, bok := e.right.value(root, current)
+	switch e.op {
+	case "==":
+		return jpEqual(a, aok, b, bok)
+	case "!=":
+		return !jpEqual(a, aok, b, bok)
+	case "<":
+		return aok && bok && jpLess(a, b)
+	case "<=":
+		return aok && bok && jpLess(a, b) || jpEqual(a, aok, b, bok)
+	case ">":
+		return aok && bok && jpLess(b, a)
+	case ">=":
+		return aok && bok && jpLess(b, a) || jpEqual(a, aok, b, bok)
+	}
+	return false
+}
+
+// jpEqual compares two values, where ok is false for Nothing, the result of
+// a query that selects no node.
+func jpEqual(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return !aok && !bok
+	}
+	return jsonEqual(a, b)
+}
+
+func jpLess(a, b interface{}) bool {
+	if _, ok := toNumber(a); ok {
+		c, ok := compareNumbers(a, b)
+		return ok && c < 0
+	}
+	x, xok := a.(string)
+	y, yok := b.(string)
+	return xok && yok && x < y
+}
+
+// toNumber returns the value of a JSON number.
+func toNumber(v interface{}) (float64, bool) {
+	switch d := v.(type) {
+	case float64:
+		return d, true
+	case float32:
+		return float64(d), true
+	case int:
+		return float64(d), true
+	case int8:
+		return float64(d), true
+	case int16:
+		return float64(d), true
+	case int32:
+		return float64(d), true
+	case int64:
+		return float64(d), true
+	case uint:
+		return float64(d), true
+	case uint8:
+		return float64(d), true
+	case uint16:
+		return float64(d), true
+	case uint32:
+		return float64(d), true
+	case uint64:
+		return float64(d), true
+	case json.Number:
+		// a number too large for float64 is still a number
+		f, err := d.Float64()
+		return f, err == nil || errors.Is(err, strconv.ErrRange)
+	}
+	return 0, false
+}
+
+// compareNumbers compares two JSON numbers, returning false if either is
+// not a number. json.Number and integer values are compared exactly, so
+// that large integers differing in the last digit are not equal. A float64
+// is compared as a float64, since a decimal such as 0.1 decoded as a
+// json.Number has no exact float64 value.
+func compareNumbers(a, b interface{}) (int, bool) {
+	x, ok := toNumber(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := toNumber(b)
+	if !ok {
+		return 0, false
+	}
+	if !isFloat(a) && !isFloat(b) {
+		if r, err := toRat(a); err == nil {
+			if s, err := toRat(b); err == nil {
+				return r.Cmp(s), true
+			}
+		}
+		// big.Rat does not accept very large exponents
+		r, _, err := big.ParseFloat(fmt.Sprint(a), 10, 1024, big.ToNearestEven)
+		if err == nil {
+			if s, _, err := big.ParseFloat(fmt.Sprint(b), 10, 1024, big.ToNearestEven); err == nil {
+				return r.Cmp(s), true
+			}
+		}
+		if m, ok := a.(json.Number); ok {
+			if n, ok := b.(json.Number); ok && m == n {
+				return 0, true
+			}
+		}
+	}
+	return cmp.Compare(x, y), true
+}
+
+func isFloat(v interface{}) bool {
+	switch v.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+// jsonEqual reports whether two JSON values are equal, comparing numbers
+// by value and arrays and objects deeply.
+func jsonEqual(a, b interface{}) bool {
+	a, b = unwrap(a), unwrap(b)
+	if _, ok := toNumber(a); ok {
+		c, ok := compareNumbers(a, b)
+		return ok && c == 0
+	}
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// filterQuery is a query within a filter, relative to the current node @
+// or to the root $.
+type filterQuery struct {
+	relative bool
+	singular bool
+	segs     []querySegment
+}
+
+func (q *filterQuery) eval(root, current interface{}) []jpNode {
+	start := jpNode{path: "$", value: root}
+	if q.relative {
+		start = jpNode{path: "@", value: current}
+	}
+	return evalSegments(q.segs, root, []jpNode{start})
+}
+
+// operand is a literal, a query or a function in a filter expression.
+type operand struct {
+	literal interface{}
+	query   *filterQuery
+	fn      *funcExpr
+}
+
+// value returns the value of a comparable operand, with ok false for
+// Nothing.
+func (o *operand) value(root, current interface{}) (interface{}, bool) {
+	switch {
+	case o.query != nil:
+		nodes := o.query.eval(root, current)
+		if len(nodes) == 1 {
+			return nodes[0].value, true
+		}
+		return nil, false
+	case o.fn != nil:
+		r := o.fn.call(root, current).(jpValue)
+		return r.v, r.ok
+	}
+	return o.literal, true
+}
+
+// jpValue is a ValueType result, where ok is false for Nothing.
+type jpValue struct {
+	v  interface{}
+	ok bool
+}
+
+type funcType int
+
+const (
+	valueType funcType = iota
+	logicalType
+	nodesType
+)
+
+type funcDef struct {
+	params []funcType
+	result funcType
+	call   func(args []interface{}) interface{}
+}
+
+var funcs = map[string]*funcDef{
+	"length": {
+		params: []funcType{valueType},
+		result: valueType,
+		call: func(args []interface{}) interface{} {
+			switch d := args[0].(jpValue).v.(type) {
+			case string:
+				return jpValue{float64(utf8.RuneCountInString(d)), true}
+			case []interface{}:
+				return jpValue{float64(len(d)), true}
+			case map[string]interface{}:
+				return jpValue{float64(len(d)), true}
+			}
+			return jpValue{}
+		},
+	},
+	"count": {
+		params: []funcType{nodesType},
+		result: valueType,
+		call: func(args []interface{}) interface{} {
+			return jpValue{float64(len(args[0].([]jpNode))), true}
+		},
+	},
+	"match": {
+		params: []funcType{valueType, valueType},
+		result: logicalType,
+		call: func(args []interface{}) interface{} {
+			return regexpTest(args, true)
+		},
+	},
+	"search": {
+		params: []funcType{valueType, valueType},
+		result: logicalType,
+		call: func(args []interface{}) interface{} {
+			return regexpTest(args, false)
+		},
+	},
+	"value": {
+		params: []funcType{nodesType},
+		result: valueType,
+		call: func(args []interface{}) interface{} {
+			if nodes := args[0].([]jpNode); len(nodes) == 1 {
+				return jpValue{nodes[0].value, true}
+			}
+			return jpValue{}
+		},
+	},
+}
+
+// neverMatch stands for an invalid pattern, which matches no string.
+var neverMatch = regexp.MustCompile(`[^\x00-\x{10FFFF}]`)
+
+// compileIRegexp compiles an I-Regexp (RFC 9485) for the whole string if
+// full is true.
+func compileIRegexp(pattern string, full bool) *regexp.Regexp {
+	if full {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	re, err := regexp.Compile(translateIRegexp(pattern))
+	if err != nil {
+		return neverMatch
+	}
+	return re
+}
+
+// regexpTest matches a string against an I-Regexp, the whole string if full
+// is true. A pattern given as a literal is compiled when the query is
+// parsed and passed as a third argument, while a pattern from the document
+// is compiled for each call rather than cached, so that untrusted data
+// cannot fill a cache.
+func regexpTest(args []interface{}, full bool) bool {
+	s, ok := args[0].(jpValue).v.(string)
+	if !ok {
+		return false
+	}
+	if len(args) > 2 {
+		return args[2].(*regexp.Regexp).MatchString(s)
+	}
+	pattern, ok := args[1].(jpValue).v.(string)
+	if !ok {
+		return false
+	}
+	return compileIRegexp(pattern, full).MatchString(s)
+}
+
+// translateIRegexp rewrites "." outside of character classes so that, as
+// in I-Regexp, it does not match "\r" either.
+func translateIRegexp(pattern string) string {
+	var b strings.Builder
+	class := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			i++
+			b.WriteByte(pattern[i])
+			continue
+		case c == '[':
+			class = true
+		case c == ']':
+			class = false
+		case c == '.' && !class:
+			b.WriteString(`[^\n\r]`)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// funcArg is an argument of a function, converted to its parameter type.
+type funcArg struct {
+	typ     funcType
+	value   *operand
+	logical logicalExpr
+	nodes   *filterQuery
+	fn      *funcExpr
+}
+
+type funcExpr struct {
+	name string
+	def  *funcDef
+	args []funcArg
+	re   *regexp.Regexp // literal pattern of match() or search()
+}
+
+func (f *funcExpr) call(root, current interface{}) interface{} {
+	args := make([]interface{}, len(f.args))
+	for i, a := range f.args {
+		switch a.typ {
+		case valueType:
+			v, ok := a.value.value(root, current)
+			args[i] = jpValue{v, ok}
+		case logicalType:
+			args[i] = a.logical.test(root, current)
+		case nodesType:
+			if a.fn != nil {
+				args[i] = a.fn.call(root, current)
+			} else {
+				args[i] = a.nodes.eval(root, current)
+			}
+		}
+	}
+	if f.re != nil {
+		args = append(args, f.re)
+	}
+	return f.def.call(args)
+}
+
+type jpParser struct {
+	s   string
+	pos int
+}
+
+func (p *jpParser) errorf(format string, args ...interface{}) error {
+	return &QueryError{Query: p.s, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *jpParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *jpParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *jpParser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseSegments parses the segments following "$" or "@".
+func (p *jpParser) parseSegments() ([]querySegment, error) {
+	var segs []querySegment
+	for {
+		start := p.pos
+		p.skipSpace()
+		seg := querySegment{}
+		var err error
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				seg.selectors, err = p.parseBracketed()
+			} else {
+				seg.selectors, err = p.parseDotted()
+			}
+		case p.consume("."):
+			seg.selectors, err = p.parseDotted()
+		case p.peek() == '[':
+			seg.selectors, err = p.parseBracketed()
+		default:
+			p.pos = start
+			return segs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+}
+
+// parseDotted parses the wildcard or member name following a dot.
+func (p *jpParser) parseDotted() ([]selector, error) {
+	switch {
+	case p.consume("*"):
+		return []selector{wildcardSelector{}}, nil
+	case p.isNameFirst():
+		return []selector{nameSelector(p.parseShorthand())}, nil
+	}
+	return nil, p.errorf("expected a member name or *")
+}
+
+func (p *jpParser) isNameFirst() bool {
+	if p.pos >= len(p.s) {
+		return false
+	}
+	c := p.s[p.pos]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func (p *jpParser) parseShorthand() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if p.isNameFirst() || c >= '0' && c <= '9' {
+			if c >= 0x80 {
+				_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+				p.pos += size
+			} else {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *jpParser) parseBracketed() ([]selector, error) {
+	p.pos++ // [
+	var sels []selector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return sels, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *jpParser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector(s), nil
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr}, nil
+	}
+
+	var s sliceSelector
+	var err error
+	if s.start, err = p.parseOptionalInt(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume(":") {
+		if s.start == nil {
+			return nil, p.errorf("expected a selector")
+		}
+		return indexSelector(*s.start), nil
+	}
+	p.skipSpace()
+	if s.end, err = p.parseOptionalInt(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.consume(":") {
+		p.skipSpace()
+		if s.step, err = p.parseOptionalInt(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// maxSafeInt is the I-JSON integer limit 2^53-1.
+const maxSafeInt = 1<<53 - 1
+
+func (p *jpParser) parseOptionalInt() (*int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == digits {
+		if p.pos != start {
+			return nil, p.errorf("expected digits")
+		}
+		return nil, nil
+	}
+	s := p.s[start:p.pos]
+	if p.s[digits] == '0' && (p.pos-digits > 1 || digits > start) {
+		p.pos = start
+		return nil, p.errorf("invalid integer %q", s)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n > maxSafeInt || n < -maxSafeInt {
+		p.pos = start
+		return nil, p.errorf("integer %q is out of range", s)
+	}
+	i := int(n)
+	return &i, nil
+}
+
+// parseString parses a single or double quoted string literal.
+func (p *jpParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.s) {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		p.pos++
+		if p.pos >= len(p.s) {
+			return "", p.errorf("unterminated string")
+		}
+		c = p.s[p.pos]
+		p.pos++
+		switch c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\':
+			b.WriteByte(c)
+		case 'u':
+			r, err := p.parseHex()
+			if err != nil {
+				return "", err
+			}
+			if utf16.IsSurrogate(r) {
+				if !p.consume(`\u`) {
+					return "", p.errorf("unpaired surrogate")
+				}
+				r2, err := p.parseHex()
+				if err != nil {
+					return "", err
+				}
+				if r = utf16.DecodeRune(r, r2); r == utf8.RuneError {
+					return "", p.errorf("invalid surrogate pair")
+				}
+			}
+			b.WriteRune(r)
+		default:
+			if c != quote {
+				p.pos--
+				return "", p.errorf("invalid escape \\%c", c)
+			}
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *jpParser) parseHex() (rune, error) {
+	if p.pos+4 > len(p.s) {
+		return 0, p.errorf("invalid \\u escape")
+	}
+	n, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid \\u escape")
+	}
+	p.pos += 4
+	return rune(n), nil
+}
+
+func (p *jpParser) parseOr() (logicalExpr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := orExpr{x}
+	for {
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("||") {
+			p.pos = start
+			break
+		}
+		p.skipSpace()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, y)
+	}
+	if len(or) == 1 {
+		return x, nil
+	}
+	return or, nil
+}
+
+func (p *jpParser) parseAnd() (logicalExpr, error) {
+	x, err := p.parseBasic()
+	if err != nil {
+		return nil, err
+	}
+	and := andExpr{x}
+	for {
+		start := p.pos
+		p.skipSpace()
+		if !p.consume("&&") {
+			p.pos = start
+			break
+		}
+		p.skipSpace()
+		y, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, y)
+	}
+	if len(and) == 1 {
+		return x, nil
+	}
+	return and, nil
+}
+
+func (p *jpParser) parseBasic() (logicalExpr, error) {
+	if p.peek() == '!' {
+		p.pos++
+		p.skipSpace()
+		var x logicalExpr
+		var err error
+		if p.peek() == '(' {
+			x, err = p.parseParen()
+		} else {
+			x, err = p.parseTest()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	}
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+
+	start := p.pos
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := p.parseCompareOp()
+	if op == "" {
+		p.pos = start
+		return p.parseTest()
+	}
+	if err := p.checkComparable(left, start); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	rightStart := p.pos
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkComparable(right, rightStart); err != nil {
+		return nil, err
+	}
+	return compareExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *jpParser) parseParen() (logicalExpr, error) {
+	p.pos++ // (
+	p.skipSpace()
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume(")") {
+		return nil, p.errorf("expected )")
+	}
+	return x, nil
+}
+
+// parseTest parses a query or a function as a test expression.
+func (p *jpParser) parseTest() (logicalExpr, error) {
+	start := p.pos
+	o, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case o.query != nil:
+		return existExpr{o.query}, nil
+	case o.fn != nil && o.fn.def.result != valueType:
+		return funcTest{o.fn}, nil
+	}
+	p.pos = start
+	return nil, p.errorf("expected a query, a function returning a logical value or a comparison")
+}
+
+func (p *jpParser) parseCompareOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// checkComparable checks that an operand of a comparison is a literal, a
+// singular query or a function returning a value.
+func (p *jpParser) checkComparable(o *operand, pos int) error {
+	if o.query != nil && !o.query.singular {
+		return &QueryError{Query: p.s, Offset: pos, Msg: "query in comparison must be singular"}
+	}
+	if o.fn != nil && o.fn.def.result != valueType {
+		return &QueryError{Query: p.s, Offset: pos, Msg: o.fn.name + "() does not return a value"}
+	}
+	return nil
+}
+
+func (p *jpParser) parseOperand() (*operand, error) {
+	c := p.peek()
+	switch {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return &operand{query: &filterQuery{relative: c == '@', singular: isSingular(segs), segs: segs}}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &operand{literal: s}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	}
+	for _, lit := range []struct {
+		name  string
+		value interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if p.consume(lit.name) {
+			return &operand{literal: lit.value}, nil
+		}
+	}
+	if c >= 'a' && c <= 'z' {
+		return p.parseFunc()
+	}
+	return nil, p.errorf("expected a literal, a query or a function")
+}
+
+func isSingular(segs []querySegment) bool {
+	for _, seg := range segs {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *jpParser) parseNumber() (*operand, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == digits || (p.s[digits] == '0' && p.pos-digits > 1) {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+	if p.consume(".") {
+		frac := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos == frac {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	if p.consume("e") || p.consume("E") {
+		if !p.consume("+") {
+			p.consume("-")
+		}
+		exp := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos == exp {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil || math.IsInf(f, 0) {
+		p.pos = start
+		return nil, p.errorf("invalid number")
+	}
+	// compare with decoded numbers as json.Number does
+	return &operand{literal: json.Number(p.s[start:p.pos])}, nil
+}
+
+func (p *jpParser) parseFunc() (*operand, error) {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	name := p.s[start:p.pos]
+	def, ok := funcs[name]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function %s", name)
+	}
+	if !p.consume("(") {
+		return nil, p.errorf("expected (")
+	}
+	fn := &funcExpr{name: name, def: def}
+	for {
+		p.skipSpace()
+		if len(fn.args) == 0 && p.consume(")") {
+			break
+		}
+		if len(fn.args) == len(def.params) {
+			return nil, p.errorf("too many arguments to %s()", name)
+		}
+		arg, err := p.parseArg(def.params[len(fn.args)])
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, arg)
+		p.skipSpace()
+		if p.consume(")") {
+			break
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+	if len(fn.args) != len(def.params) {
+		return nil, p.errorf("%s() takes %d arguments", name, len(def.params))
+	}
+	if name == "match" || name == "search" {
+		if pattern := fn.args[1].value; pattern.query == nil && pattern.fn == nil {
+			if s, ok := pattern.literal.(string); ok {
+				fn.re = compileIRegexp(s, name == "match")
+			}
+		}
+	}
+	return &operand{fn: fn}, nil
+}
+
+// parseArg parses a function argument of the parameter type typ.
+func (p *jpParser) parseArg(typ funcType) (funcArg, error) {
+	start := p.pos
+	arg := funcArg{typ: typ}
+	if typ == logicalType {
+		x, err := p.parseOr()
+		if err != nil {
+			return arg, err
+		}
+		arg.logical = x
+		return arg, nil
+	}
+	o, err := p.parseOperand()
+	if err != nil {
+		return arg, err
+	}
+	switch typ {
+	case valueType:
+		if err := p.checkComparable(o, start); err != nil {
+			return arg, err
+		}
+		arg.value = o
+	case nodesType:
+		switch {
+		case o.query != nil:
+			arg.nodes = o.query
+		case o.fn != nil && o.fn.def.result == nodesType:
+			arg.fn = o.fn
+		default:
+			return arg, &QueryError{Query: p.s, Offset: start, Msg: "argument must be a query"}
+		}
+	}
+	return arg, nil
+}
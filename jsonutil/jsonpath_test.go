@@ -0,0 +1,213 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const storeDoc = `{"store": {
+	"book": [
+		{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+		{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+		{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+		{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+	],
+	"bicycle": {"color": "red", "price": 399}
+}}`
+
+func queryPaths(t *testing.T, v *Value, expr string) string {
+	matches, err := v.Query(expr)
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = m.Path
+	}
+	return strings.Join(paths, " ")
+}
+
+func TestJSONPath(t *testing.T) {
+	v, err := DecodeString(storeDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		e    string
+	}{
+		{"$.store.book[*].author", "$['store']['book'][0]['author'] $['store']['book'][1]['author'] $['store']['book'][2]['author'] $['store']['book'][3]['author']"},
+		{"$..author", "$['store']['book'][0]['author'] $['store']['book'][1]['author'] $['store']['book'][2]['author'] $['store']['book'][3]['author']"},
+		{"$.store.*", "$['store']['bicycle'] $['store']['book']"},
+		{"$.store..price", "$['store']['bicycle']['price'] $['store']['book'][0]['price'] $['store']['book'][1]['price'] $['store']['book'][2]['price'] $['store']['book'][3]['price']"},
+		{"$..book[2]", "$['store']['book'][2]"},
+		{"$..book[-1]", "$['store']['book'][3]"},
+		{"$..book[0,1]", "$['store']['book'][0] $['store']['book'][1]"},
+		{"$..book[:2]", "$['store']['book'][0] $['store']['book'][1]"},
+		{"$..book[::-2]", "$['store']['book'][3] $['store']['book'][1]"},
+		{"$..book[1:10:2]", "$['store']['book'][1] $['store']['book'][3]"},
+		{"$..book[?@.isbn]", "$['store']['book'][2] $['store']['book'][3]"},
+		{"$..book[?@.price<10]", "$['store']['book'][0] $['store']['book'][2]"},
+		{"$..book[?@.price < 10 && @.category == 'fiction']", "$['store']['book'][2]"},
+		{"$..book[?!(@.price < 10) || @.isbn]", "$['store']['book'][1] $['store']['book'][2] $['store']['book'][3]"},
+		{"$..book[?@.price <= $.store.bicycle.price]", "$['store']['book'][0] $['store']['book'][1] $['store']['book'][2] $['store']['book'][3]"},
+		{"$..book[?length(@.title) > 20]", "$['store']['book'][0] $['store']['book'][3]"},
+		{"$..book[?match(@.author, 'H.*')]", "$['store']['book'][2]"},
+		{"$..book[?search(@.title, 'of')]", "$['store']['book'][0] $['store']['book'][1] $['store']['book'][3]"},
+		{"$.store[?count(@.*) == 2]", "$['store']['bicycle']"},
+		{"$..book[?value(@..isbn) == '0-553-21311-3']", "$['store']['book'][2]"},
+		{"$['store']['bicycle'][\"color\"]", "$['store']['bicycle']['color']"},
+		{"$.store.none", ""},
+		{"$", "$"},
+	}
+	for _, test := range tests {
+		if r := queryPaths(t, v, test.expr); r != test.e {
+			t.Errorf("%s:\n%s !=\n%s", test.expr, r, test.e)
+		}
+	}
+
+	matches, _ := v.Query("$.store.bicycle.color")
+	if s, _ := matches[0].Value.String(); s != "red" {
+		t.Errorf("%s != red", s)
+	}
+}
+
+func TestJSONPathCompare(t *testing.T) {
+	v, _ := DecodeString(`{"a": [1, "1", true, null, [1], {"b": 1}, {"b": 2}, {}]}`)
+	tests := []struct {
+		expr string
+		e    string
+	}{
+		{"$.a[?@ == 1]", "$['a'][0]"},
+		{"$.a[?@ == '1']", "$['a'][1]"},
+		{"$.a[?@ == null]", "$['a'][3]"},
+		{"$.a[?@ == true]", "$['a'][2]"},
+		{"$.a[?@.b == @.c]", "$['a'][0] $['a'][1] $['a'][2] $['a'][3] $['a'][4] $['a'][7]"},
+		{"$.a[?@.b != 1]", "$['a'][0] $['a'][1] $['a'][2] $['a'][3] $['a'][4] $['a'][6] $['a'][7]"},
+		{"$.a[?@ < 2]", "$['a'][0]"},
+		{"$.a[?@ >= '1']", "$['a'][1]"},
+		{"$.a[?@ == $.a[4]]", "$['a'][4]"},
+	}
+	for _, test := range tests {
+		if r := queryPaths(t, v, test.expr); r != test.e {
+			t.Errorf("%s:\n%s !=\n%s", test.expr, r, test.e)
+		}
+	}
+}
+
+func TestJSONPathNormalizedPath(t *testing.T) {
+	v, _ := DecodeString(`{"it's": {"a\\b\n": 1}}`)
+	e := `$['it\'s']['a\\b\n']`
+	if r := queryPaths(t, v, "$.*.*"); r != e {
+		t.Errorf("%s != %s", r, e)
+	}
+	if r := queryPaths(t, v, `$["it's"]['a\\b\n']`); r != e {
+		t.Errorf("%s != %s", r, e)
+	}
+}
+
+func TestJSONPathError(t *testing.T) {
+	tests := []struct {
+		expr   string
+		offset int
+	}{
+		{"store", 0},
+		{"$.", 2},
+		{"$.[0]", 2},
+		{"$[01]", 2},
+		{"$[0", 3},
+		{"$['a]", 5},
+		{"$[?@.a == @..b]", 10},
+		{"$[?@.a < 1 &&]", 13},
+		{"$[?1]", 3},
+		{"$[?length(@.a)]", 3},
+		{"$[?count(1) == 1]", 9},
+		{"$[?nope(@)]", 3},
+		{"$.a ", 3},
+	}
+	for _, test := range tests {
+		_, err := CompileJSONPath(test.expr)
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Errorf("%s: %v is not a QueryError", test.expr, err)
+			continue
+		}
+		if qe.Offset != test.offset {
+			t.Errorf("%s: %v", test.expr, err)
+		}
+	}
+}
+
+func TestJSONPathDecimal(t *testing.T) {
+	v := mustDecode(t, `{"items": [{"price": 9.99}, {"price": 0.1}, {"price": 9007199254740993}]}`)
+	tests := []struct {
+		expr string
+		e    string
+	}{
+		{"$.items[?@.price == 9.99]", "$['items'][0]"},
+		{"$.items[?@.price <= 0.1]", "$['items'][1]"},
+		{"$.items[?@.price >= 9.99 && @.price < 10]", "$['items'][0]"},
+		{"$.items[?@.price == 9007199254740993]", "$['items'][2]"},
+		{"$.items[?@.price == 9007199254740992]", ""},
+	}
+	for _, test := range tests {
+		if s := queryPaths(t, v, test.expr); s != test.e {
+			t.Errorf("%s: %s != %s", test.expr, s, test.e)
+		}
+	}
+
+	// the same document built in Go holds float64
+	v = &Value{map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"price": 9.99},
+		map[string]interface{}{"price": 0.1},
+	}}}
+	if s := queryPaths(t, v, "$.items[?@.price == 0.1]"); s != "$['items'][1]" {
+		t.Errorf("%s", s)
+	}
+}
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		e    bool
+	}{
+		{json.Number("9.99"), 9.99, true},
+		{0.1, json.Number("0.1"), true},
+		{json.Number("0.1"), json.Number("0.10"), true},
+		{json.Number("9007199254740993"), json.Number("9007199254740992"), false},
+		{json.Number("9007199254740993"), int64(9007199254740993), true},
+		{json.Number("1e10000000"), json.Number("1e10000000"), true},
+		{json.Number("1e10000000"), json.Number("2e10000000"), false},
+		{json.Number("1"), "1", false},
+	}
+	for _, test := range tests {
+		if jsonEqual(test.a, test.b) != test.e {
+			t.Errorf("%#v == %#v != %v", test.a, test.b, test.e)
+		}
+	}
+}
+
+func TestJSONPathRegexp(t *testing.T) {
+	q := MustCompileJSONPath("$[?match(@.a, 'a.c')]")
+	if q.segs[0].selectors[0].(filterSelector).expr.(funcTest).fn.re == nil {
+		t.Error("literal pattern is not compiled")
+	}
+
+	v := mustDecode(t, `[{"a": "abc", "p": "a.*"}, {"a": "abc", "p": "b"}, {"a": "abc", "p": "("}]`)
+	tests := []struct {
+		expr string
+		e    string
+	}{
+		{"$[?match(@.a, @.p)]", "$[0]"},
+		{"$[?search(@.a, @.p)]", "$[0] $[1]"},
+		{"$[?match(@.a, 'a.c')]", "$[0] $[1] $[2]"},
+		{"$[?search(@.a, '(')]", ""},
+	}
+	for _, test := range tests {
+		if s := queryPaths(t, v, test.expr); s != test.e {
+			t.Errorf("%s: %s != %s", test.expr, s, test.e)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrTestFailed = errors.New("test failed")
+
+// Operation is a JSON Patch operation as defined by RFC 6902.
+type Operation struct {
+	Op    string // add, remove, replace, move, copy or test
+	Path  string
+	From  string      // for move and copy
+	Value interface{} // for add, replace and test
+}
+
+// Patch is a JSON Patch document.
+type Patch []Operation
+
+// PatchError records the operation of a patch that failed.
+type PatchError struct {
+	Index int
+	Op    Operation
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("jsonutil: patch operation %d (%s %q): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"op": o.Op, "path": o.Path}
+	switch o.Op {
+	case "move", "copy":
+		m["from"] = o.From
+	case "add", "replace", "test":
+		m["value"] = unwrap(o.Value)
+	}
+	return json.Marshal(m)
+}
+
+func (o *Operation) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	var op Operation
+	if err := unmarshalMember(m, "op", &op.Op); err != nil {
+		return err
+	}
+	if err := unmarshalMember(m, "path", &op.Path); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		var value Value
+		if err := unmarshalMember(m, "value", &value); err != nil {
+			return err
+		}
+		op.Value = value.Value
+	case "move", "copy":
+		if err := unmarshalMember(m, "from", &op.From); err != nil {
+			return err
+		}
+	case "remove":
+	default:
+		return fmt.Errorf("jsonutil: unknown patch operation %q", op.Op)
+	}
+	*o = op
+	return nil
+}
+
+// unmarshalMember decodes a required member of a patch operation.
+func unmarshalMember(m map[string]json.RawMessage, name string, dst interface{}) error {
+	raw, ok := m[name]
+	if !ok {
+		return fmt.Errorf("jsonutil: patch operation has no %q", name)
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// DecodePatch decodes a JSON Patch document.
+func DecodePatch(b []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(b, &patch); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// Apply applies a patch to v. If an operation fails, v is left unchanged
+// and a PatchError is returned.
+func (v *Value) Apply(patch Patch) error {
+	doc := &Value{deepCopy(v.Value)}
+	for i, op := range patch {
+		if err := doc.apply(op); err != nil {
+			return &PatchError{Index: i, Op: op, Err: err}
+		}
+	}
+	v.Value = doc.Value
+	return nil
+}
+
+func (v *Value) apply(op Operation) error {
+	p, err := parsePointer(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add":
+		return v.add(p, deepCopy(op.Value))
+	case "remove":
+		return v.deleteAt(p, nil)
+	case "replace":
+		if _, err := p.get(v.Value); err != nil {
+			return err
+		}
+		return v.setAt(p, nil, deepCopy(op.Value))
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		value, err := from.get(v.Value)
+		if err != nil {
+			return err
+		}
+		if op.Op == "copy" {
+			return v.add(p, deepCopy(value))
+		}
+		if op.Path == op.From {
+			return nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %q into itself", op.From)
+		}
+		if err := v.deleteAt(from, nil); err != nil {
+			return err
+		}
+		return v.add(p, value)
+	case "test":
+		value, err := p.get(v.Value)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(value, op.Value) {
+			return ErrTestFailed
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// add inserts a value, shifting the elements of an array after it.
+func (v *Value) add(p *path, value interface{}) error {
+	r, err := p.set(v.Value, 0, value, true)
+	if err != nil {
+		return err
+	}
+	v.Value = r
+	return nil
+}
+
+// deepCopy copies the objects and arrays of a JSON value.
+func deepCopy(v interface{}) interface{} {
+	switch d := unwrap(v).(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(d))
+		for k, e := range d {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(d))
+		for i, e := range d {
+			a[i] = deepCopy(e)
+		}
+		return a
+	default:
+		return d
+	}
+}
+
+// MergePatch returns the result of applying a JSON Merge Patch (RFC 7386)
+// to doc. Members of patch replace those of doc, except that objects are
+// merged recursively and null removes a member. doc is not modified.
+func MergePatch(doc, patch *Value) *Value {
+	return &Value{mergePatch(deepCopy(doc.Value), unwrap(patch.Value))}
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v = unwrap(v); v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(unwrap(t[k]), v)
+		}
+	}
+	return t
+}
+
+// Diff returns a patch that turns a into b. Arrays are compared element by
+// element with the fewest additions, removals and replacements. The patch
+// is empty, not nil, if a and b are equal, so that it marshals as [].
+func Diff(a, b *Value) Patch {
+	return diff(Patch{}, "", unwrap(a.Value), unwrap(b.Value))
+}
+
+// pointerToken escapes a member name or index for a JSON Pointer.
+func pointerToken(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
+
+func diff(patch Patch, ptr string, a, b interface{}) Patch {
+	if jsonEqual(a, b) {
+		return patch
+	}
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(x)+len(y))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		for k := range y {
+			if _, ok := x[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := ptr + "/" + pointerToken(k)
+			xv, xok := x[k]
+			yv, yok := y[k]
+			switch {
+			case !yok:
+				patch = append(patch, Operation{Op: "remove", Path: p})
+			case !xok:
+				patch = append(patch, Operation{Op: "add", Path: p, Value: deepCopy(yv)})
+			default:
+				patch = diff(patch, p, unwrap(xv), unwrap(yv))
+			}
+		}
+		return patch
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		return diffArray(patch, ptr, x, y)
+	}
+	return append(patch, Operation{Op: "replace", Path: ptr, Value: deepCopy(b)})
+}
+
+// maxDiffCells bounds the table diffArray computes, len(a)*len(b) after
+// the common prefix and suffix are trimmed. Larger arrays are replaced
+// whole.
+var maxDiffCells = 1 << 20
+
+// diffArray appends the edits of the shortest edit script from a to b,
+// computed by dynamic programming over the elements.
+func diffArray(patch Patch, ptr string, a, b []interface{}) Patch {
+	whole := b
+	start := 0
+	for start < len(a) && start < len(b) && jsonEqual(a[start], b[start]) {
+		start++
+	}
+	a, b = a[start:], b[start:]
+	for len(a) > 0 && len(b) > 0 && jsonEqual(a[len(a)-1], b[len(b)-1]) {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	n, m := len(a), len(b)
+	if n > 0 && m > maxDiffCells/n {
+		return append(patch, Operation{Op: "replace", Path: ptr, Value: deepCopy(whole)})
+	}
+	// cost[i][j] is the number of edits from a[i:] to b[j:]
+	cost := make([][]int, n+1)
+	for i := range cost {
+		cost[i] = make([]int, m+1)
+	}
+	for i := n; i >= 0; i-- {
+		for j := m; j >= 0; j-- {
+			switch {
+			case i == n:
+				cost[i][j] = m - j
+			case j == m:
+				cost[i][j] = n - i
+			case jsonEqual(a[i], b[j]):
+				cost[i][j] = cost[i+1][j+1]
+			default:
+				cost[i][j] = 1 + min(cost[i+1][j+1], cost[i+1][j], cost[i][j+1])
+			}
+		}
+	}
+
+	i, j, k := 0, 0, start // k is the index in the array being patched
+	for i < n || j < m {
+		p := fmt.Sprintf("%s/%d", ptr, k)
+		switch {
+		case i < n && j < m && jsonEqual(a[i], b[j]):
+			i, j, k = i+1, j+1, k+1
+		case i < n && j < m && cost[i][j] == 1+cost[i+1][j+1]:
+			patch = diff(patch, p, unwrap(a[i]), unwrap(b[j]))
+			i, j, k = i+1, j+1, k+1
+		case i < n && cost[i][j] == 1+cost[i+1][j]:
+			patch = append(patch, Operation{Op: "remove", Path: p})
+			i++
+		default:
+			patch = append(patch, Operation{Op: "add", Path: p, Value: deepCopy(b[j])})
+			j, k = j+1, k+1
+		}
+	}
+	return patch
+}
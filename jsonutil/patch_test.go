@@ -0,0 +1,214 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		e     string
+	}{
+		// examples from RFC 6902 appendix A
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz": "qux", "foo": ["a", 2, "c"]}`, `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo": null}`, `[{"op": "test", "path": "/foo", "value": null}]`, `{"foo":null}`},
+		{`{"foo": 1}`, `[{"op": "copy", "from": "/foo", "path": "/bar"}]`, `{"bar":1,"foo":1}`},
+		{`{"foo": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+	}
+	for _, test := range tests {
+		v, _ := DecodeString(test.doc)
+		patch, err := DecodePatch([]byte(test.patch))
+		if err != nil {
+			t.Fatalf("%s: %v", test.patch, err)
+		}
+		if err := v.Apply(patch); err != nil {
+			t.Errorf("%s: %v", test.patch, err)
+			continue
+		}
+		if s := marshalString(t, v.Value); s != test.e {
+			t.Errorf("%s: %s != %s", test.patch, s, test.e)
+		}
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	tests := []struct {
+		patch string
+		index int
+		err   error
+	}{
+		{`[{"op": "add", "path": "/a/b", "value": 1}, {"op": "add", "path": "/x/y", "value": 1}]`, 1, ErrNotFound},
+		{`[{"op": "remove", "path": "/a/c"}]`, 0, ErrNotFound},
+		{`[{"op": "replace", "path": "/z", "value": 1}]`, 0, ErrNotFound},
+		{`[{"op": "add", "path": "/l/5", "value": 1}]`, 0, ErrOutOfRange},
+		{`[{"op": "remove", "path": "/l/0"}, {"op": "test", "path": "/l/0", "value": 1}]`, 1, ErrTestFailed},
+		{`[{"op": "move", "from": "/a", "path": "/a/b"}]`, 0, nil},
+	}
+	for _, test := range tests {
+		v, _ := DecodeString(`{"a": {"b": 1}, "l": [1, 2]}`)
+		patch, err := DecodePatch([]byte(test.patch))
+		if err != nil {
+			t.Fatalf("%s: %v", test.patch, err)
+		}
+		err = v.Apply(patch)
+		var pe *PatchError
+		if !errors.As(err, &pe) || pe.Index != test.index || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%s: %v", test.patch, err)
+		}
+		// the document is rolled back
+		if s := marshalString(t, v.Value); s != `{"a":{"b":1},"l":[1,2]}` {
+			t.Errorf("%s: %s", test.patch, s)
+		}
+	}
+}
+
+func TestDecodePatchError(t *testing.T) {
+	for _, s := range []string{
+		`[{"op": "add", "path": "/a"}]`,
+		`[{"op": "move", "path": "/a"}]`,
+		`[{"op": "jump", "path": "/a"}]`,
+		`[{"path": "/a"}]`,
+		`{}`,
+	} {
+		if _, err := DecodePatch([]byte(s)); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+
+	patch := Patch{{Op: "add", Path: "/a", Value: nil}, {Op: "move", From: "/a", Path: "/b"}}
+	b, _ := json.Marshal(patch)
+	e := `[{"op":"add","path":"/a","value":null},{"from":"/a","op":"move","path":"/b"}]`
+	if string(b) != e {
+		t.Errorf("%s != %s", b, e)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7386 appendix A
+	tests := []struct {
+		doc   string
+		patch string
+		e     string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		doc, _ := DecodeString(test.doc)
+		patch, _ := DecodeString(test.patch)
+		r := MergePatch(doc, patch)
+		if s := marshalString(t, r.Value); s != test.e {
+			t.Errorf("%s + %s: %s != %s", test.doc, test.patch, s, test.e)
+		}
+		if s := marshalString(t, doc.Value); s != marshalString(t, mustDecode(t, test.doc).Value) {
+			t.Errorf("%s was modified to %s", test.doc, s)
+		}
+	}
+}
+
+func mustDecode(t *testing.T, s string) *Value {
+	v, err := DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		e    string
+	}{
+		{`{"a":1}`, `{"a":1}`, `[]`},
+		{`[1,2]`, `[1,2.0]`, `[]`},
+		{`{"a":1,"b":2}`, `{"a":3,"c":4}`, `[{"op":"replace","path":"/a","value":3},{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":4}]`},
+		{`{"a":{"b":[1,2,3]}}`, `{"a":{"b":[1,3]}}`, `[{"op":"remove","path":"/a/b/1"}]`},
+		{`[1,2,3]`, `[0,1,2,3,4]`, `[{"op":"add","path":"/0","value":0},{"op":"add","path":"/4","value":4}]`},
+		{`[{"id":1,"n":"a"},{"id":2}]`, `[{"id":1,"n":"b"},{"id":2}]`, `[{"op":"replace","path":"/0/n","value":"b"}]`},
+		{`{"a/b~":1}`, `{"a/b~":2}`, `[{"op":"replace","path":"/a~1b~0","value":2}]`},
+		{`{"a":1}`, `[1]`, `[{"op":"replace","path":"","value":[1]}]`},
+		{`{"id":9007199254740992}`, `{"id":9007199254740993}`, `[{"op":"replace","path":"/id","value":9007199254740993}]`},
+	}
+	for _, test := range tests {
+		a, b := mustDecode(t, test.a), mustDecode(t, test.b)
+		patch := Diff(a, b)
+		if s := marshalString(t, patch); s != test.e {
+			t.Errorf("%s -> %s: %s != %s", test.a, test.b, s, test.e)
+		}
+		if err := a.Apply(patch); err != nil {
+			t.Errorf("%s -> %s: %v", test.a, test.b, err)
+		}
+		if !jsonEqual(a.Value, b.Value) {
+			t.Errorf("%s -> %s: %s", test.a, test.b, marshalString(t, a.Value))
+		}
+	}
+}
+
+func TestDiffLargeArray(t *testing.T) {
+	defer func(n int) { maxDiffCells = n }(maxDiffCells)
+	maxDiffCells = 4
+
+	tests := []struct {
+		a, b string
+		e    string
+	}{
+		// the common prefix and suffix are not part of the table
+		{`[0,1,2,3,4,5]`, `[0,1,9,8,4,5]`, `[{"op":"replace","path":"/2","value":9},{"op":"replace","path":"/3","value":8}]`},
+		{`{"a":[1,2,3]}`, `{"a":[4,5,6]}`, `[{"op":"replace","path":"/a","value":[4,5,6]}]`},
+		{`[0,1,2,3]`, `[0,4,5,6,7,3]`, `[{"op":"replace","path":"","value":[0,4,5,6,7,3]}]`},
+	}
+	for _, test := range tests {
+		a, b := mustDecode(t, test.a), mustDecode(t, test.b)
+		patch := Diff(a, b)
+		if s := marshalString(t, patch); s != test.e {
+			t.Errorf("%s -> %s: %s != %s", test.a, test.b, s, test.e)
+		}
+		if err := a.Apply(patch); err != nil {
+			t.Errorf("%s -> %s: %v", test.a, test.b, err)
+		}
+		if !jsonEqual(a.Value, b.Value) {
+			t.Errorf("%s -> %s: %s", test.a, test.b, marshalString(t, a.Value))
+		}
+	}
+}
+
+func TestPatchDecimal(t *testing.T) {
+	v := mustDecode(t, `{"price": 0.1, "tax": 9.99}`)
+	patch := Patch{
+		{Op: "test", Path: "/price", Value: 0.1},
+		{Op: "test", Path: "/tax", Value: 9.99},
+	}
+	if err := v.Apply(patch); err != nil {
+		t.Error(err)
+	}
+
+	built := &Value{map[string]interface{}{"price": 0.1, "tax": 9.99}}
+	if patch := Diff(v, built); len(patch) != 0 {
+		t.Errorf("%s", marshalString(t, patch))
+	}
+}
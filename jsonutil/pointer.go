@@ -0,0 +1,388 @@
+package jsonutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPath = errors.New("invalid path")
+	ErrOutOfRange  = errors.New("index out of range")
+)
+
+// PathError records the segment of a JSON Pointer or dotted path that
+// failed.
+type PathError struct {
+	Path    string
+	Segment string
+	Pos     int // index of the segment in the path
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("jsonutil: %q: segment %d %q: %v", e.Path, e.Pos, e.Segment, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// path is a parsed JSON Pointer or dotted path.
+type path struct {
+	raw  string
+	segs []segment
+}
+
+type segment struct {
+	key string
+	// index is true for a dotted path "[n]" segment, which only applies to
+	// arrays. Pointer segments apply to both objects and arrays.
+	index   bool
+	pointer bool
+}
+
+func (p *path) error(pos int, err error) error {
+	seg := ""
+	if pos < len(p.segs) {
+		seg = p.segs[pos].key
+	}
+	return &PathError{Path: p.raw, Segment: seg, Pos: pos, Err: err}
+}
+
+// parsePointer parses a JSON Pointer as defined by RFC 6901, such as
+// "/a/0/b". "~1" stands for "/" and "~0" for "~" in a segment.
+func parsePointer(ptr string) (*path, error) {
+	p := &path{raw: ptr}
+	if ptr == "" {
+		return p, nil
+	}
+	if ptr[0] != '/' {
+		return nil, &PathError{Path: ptr, Segment: ptr, Err: ErrInvalidPath}
+	}
+	for i, token := range strings.Split(ptr[1:], "/") {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, &PathError{Path: ptr, Segment: token, Pos: i, Err: ErrInvalidPath}
+			}
+		}
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+		p.segs = append(p.segs, segment{key: token, pointer: true})
+	}
+	return p, nil
+}
+
+// parseDotted parses a path of keys separated by dots with array indexes
+// in brackets, such as "a.items[3].id".
+func parseDotted(s string) (*path, error) {
+	p := &path{raw: s}
+	if s == "" {
+		return p, nil
+	}
+	invalid := func(seg string) error {
+		return &PathError{Path: s, Segment: seg, Pos: len(p.segs), Err: ErrInvalidPath}
+	}
+	rest := s
+	for len(rest) > 0 {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid(rest)
+			}
+			n := rest[1:end]
+			if !isIndex(n) {
+				return nil, invalid(rest[:end+1])
+			}
+			p.segs = append(p.segs, segment{key: n, index: true})
+			rest = rest[end+1:]
+			if len(rest) > 0 && rest[0] == '.' {
+				rest = rest[1:]
+				if rest == "" {
+					return nil, invalid(".")
+				}
+			} else if len(rest) > 0 && rest[0] != '[' {
+				return nil, invalid(rest)
+			}
+			continue
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, invalid(rest)
+		}
+		p.segs = append(p.segs, segment{key: rest[:end]})
+		rest = rest[end:]
+		if len(rest) > 0 && rest[0] == '.' {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, invalid(".")
+			}
+		}
+	}
+	return p, nil
+}
+
+// isIndex reports whether s is an array index without leading zeros.
+func isIndex(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrap returns the JSON value held by v, converting Map and Array to
+// their underlying types.
+func unwrap(v interface{}) interface{} {
+	for {
+		switch d := v.(type) {
+		case *Value:
+			if d == nil {
+				return nil
+			}
+			v = d.Value
+		case Value:
+			v = d.Value
+		case Map:
+			return map[string]interface{}(d)
+		case Array:
+			return []interface{}(d)
+		default:
+			return v
+		}
+	}
+}
+
+// arrayIndex returns the index of segment pos in an array of length n.
+// If end is true, "-" and n are accepted as the index past the last element.
+func (p *path) arrayIndex(pos int, n int, end bool) (int, error) {
+	seg := p.segs[pos]
+	if seg.pointer && seg.key == "-" {
+		if end {
+			return n, nil
+		}
+		return 0, p.error(pos, ErrOutOfRange)
+	}
+	if !seg.index && !seg.pointer {
+		return 0, p.error(pos, ErrInvalidType)
+	}
+	if !isIndex(seg.key) {
+		return 0, p.error(pos, ErrInvalidPath)
+	}
+	i, err := strconv.Atoi(seg.key)
+	if err != nil || i > n || (i == n && !end) {
+		return 0, p.error(pos, ErrOutOfRange)
+	}
+	return i, nil
+}
+
+func (p *path) get(node interface{}) (interface{}, error) {
+	for pos, seg := range p.segs {
+		switch d := unwrap(node).(type) {
+		case map[string]interface{}:
+			if seg.index {
+				return nil, p.error(pos, ErrInvalidType)
+			}
+			v, ok := d[seg.key]
+			if !ok {
+				return nil, p.error(pos, ErrNotFound)
+			}
+			node = v
+		case []interface{}:
+			i, err := p.arrayIndex(pos, len(d), false)
+			if err != nil {
+				return nil, err
+			}
+			node = d[i]
+		default:
+			return nil, p.error(pos, ErrInvalidType)
+		}
+	}
+	return unwrap(node), nil
+}
+
+// set sets the value at segment pos and below in node, returning node or
+// a new array if the array was extended. A value is added to an object or
+// appended to an array at the last segment only. If insert is true, a value
+// is inserted before an existing array element instead of replacing it.
+func (p *path) set(node interface{}, pos int, value interface{}, insert bool) (interface{}, error) {
+	if pos == len(p.segs) {
+		return value, nil
+	}
+	last := pos == len(p.segs)-1
+	seg := p.segs[pos]
+	switch d := unwrap(node).(type) {
+	case map[string]interface{}:
+		if seg.index {
+			return nil, p.error(pos, ErrInvalidType)
+		}
+		child, ok := d[seg.key]
+		if !ok && !last {
+			return nil, p.error(pos, ErrNotFound)
+		}
+		v, err := p.set(child, pos+1, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		d[seg.key] = v
+		return d, nil
+	case []interface{}:
+		i, err := p.arrayIndex(pos, len(d), last)
+		if err != nil {
+			return nil, err
+		}
+		if i == len(d) {
+			return append(d, value), nil
+		}
+		if last && insert {
+			r := make([]interface{}, 0, len(d)+1)
+			r = append(r, d[:i]...)
+			r = append(r, value)
+			return append(r, d[i:]...), nil
+		}
+		v, err := p.set(d[i], pos+1, value, insert)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = v
+		return d, nil
+	}
+	return nil, p.error(pos, ErrInvalidType)
+}
+
+// delete removes the value at the last segment, returning node or a new
+// array if an element was removed from it.
+func (p *path) delete(node interface{}, pos int) (interface{}, error) {
+	last := pos == len(p.segs)-1
+	seg := p.segs[pos]
+	switch d := unwrap(node).(type) {
+	case map[string]interface{}:
+		if seg.index {
+			return nil, p.error(pos, ErrInvalidType)
+		}
+		child, ok := d[seg.key]
+		if !ok {
+			return nil, p.error(pos, ErrNotFound)
+		}
+		if last {
+			delete(d, seg.key)
+			return d, nil
+		}
+		v, err := p.delete(child, pos+1)
+		if err != nil {
+			return nil, err
+		}
+		d[seg.key] = v
+		return d, nil
+	case []interface{}:
+		i, err := p.arrayIndex(pos, len(d), false)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(d[:i:i], d[i+1:]...), nil
+		}
+		v, err := p.delete(d[i], pos+1)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = v
+		return d, nil
+	}
+	return nil, p.error(pos, ErrInvalidType)
+}
+
+func (v *Value) getAt(p *path, err error) (*Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	r, err := p.get(v.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{r}, nil
+}
+
+func (v *Value) setAt(p *path, err error, value interface{}) error {
+	if err != nil {
+		return err
+	}
+	r, err := p.set(v.Value, 0, unwrap(value), false)
+	if err != nil {
+		return err
+	}
+	v.Value = r
+	return nil
+}
+
+func (v *Value) deleteAt(p *path, err error) error {
+	if err != nil {
+		return err
+	}
+	if len(p.segs) == 0 {
+		v.Value = nil
+		return nil
+	}
+	r, err := p.delete(v.Value, 0)
+	if err != nil {
+		return err
+	}
+	v.Value = r
+	return nil
+}
+
+// Pointer returns the value at a JSON Pointer such as "/a/0/b". The empty
+// pointer refers to v itself.
+func (v *Value) Pointer(ptr string) (*Value, error) {
+	return v.getAt(parsePointer(ptr))
+}
+
+// SetPointer sets the value at a JSON Pointer. The parent of the value must
+// exist. A missing key is added to an object, and an index equal to the
+// length of an array or "-" appends to it.
+func (v *Value) SetPointer(ptr string, value interface{}) error {
+	p, err := parsePointer(ptr)
+	return v.setAt(p, err, value)
+}
+
+// DeletePointer removes the value at a JSON Pointer. Removing an array
+// element shifts the following elements.
+func (v *Value) DeletePointer(ptr string) error {
+	return v.deleteAt(parsePointer(ptr))
+}
+
+// HasPointer reports whether there is a value at a JSON Pointer.
+func (v *Value) HasPointer(ptr string) bool {
+	_, err := v.Pointer(ptr)
+	return err == nil
+}
+
+// Path returns the value at a dotted path such as "a.items[3].id".
+func (v *Value) Path(path string) (*Value, error) {
+	return v.getAt(parseDotted(path))
+}
+
+// SetPath sets the value at a dotted path as SetPointer does.
+func (v *Value) SetPath(path string, value interface{}) error {
+	p, err := parseDotted(path)
+	return v.setAt(p, err, value)
+}
+
+// DeletePath removes the value at a dotted path as DeletePointer does.
+func (v *Value) DeletePath(path string) error {
+	return v.deleteAt(parseDotted(path))
+}
+
+// HasPath reports whether there is a value at a dotted path.
+func (v *Value) HasPath(path string) bool {
+	_, err := v.Path(path)
+	return err == nil
+}
@@ -0,0 +1,158 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const pointerDoc = `{
+	"a": {"items": [{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]},
+	"a/b": 1,
+	"m~n": 2,
+	"": 3
+}`
+
+func marshalString(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestPointer(t *testing.T) {
+	v, err := DecodeString(pointerDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ptr string
+		e   string
+	}{
+		{"/a/items/3/id", "4"},
+		{"/a/items/0", `{"id":1}`},
+		{"/a~1b", "1"},
+		{"/m~0n", "2"},
+		{"/", "3"},
+	}
+	for _, test := range tests {
+		r, err := v.Pointer(test.ptr)
+		if err != nil {
+			t.Errorf("%s: %v", test.ptr, err)
+			continue
+		}
+		if s := marshalString(t, r.Value); s != test.e {
+			t.Errorf("%s: %s != %s", test.ptr, s, test.e)
+		}
+	}
+	if r, err := v.Pointer(""); err != nil || r.Value == nil {
+		t.Errorf("whole document: %v", err)
+	}
+}
+
+func TestPointerError(t *testing.T) {
+	v, _ := DecodeString(pointerDoc)
+	tests := []struct {
+		ptr string
+		seg string
+		pos int
+		err error
+	}{
+		{"/a/none/0", "none", 1, ErrNotFound},
+		{"/a/items/4", "4", 2, ErrOutOfRange},
+		{"/a/items/01", "01", 2, ErrInvalidPath},
+		{"/a/items/-", "-", 2, ErrOutOfRange},
+		{"/a/items/0/id/x", "x", 4, ErrInvalidType},
+		{"/a~2", "a~2", 0, ErrInvalidPath},
+		{"a", "a", 0, ErrInvalidPath},
+	}
+	for _, test := range tests {
+		_, err := v.Pointer(test.ptr)
+		var pe *PathError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: %v is not a PathError", test.ptr, err)
+			continue
+		}
+		if pe.Segment != test.seg || pe.Pos != test.pos || !errors.Is(err, test.err) {
+			t.Errorf("%s: %v", test.ptr, err)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	v, _ := DecodeString(pointerDoc)
+	r, err := v.Path("a.items[3].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, _ := r.Int(); i != 4 {
+		t.Errorf("%d != 4", i)
+	}
+	if !v.HasPath("a.items[0]") || v.HasPath("a.items[4]") || v.HasPath("a.items.0") {
+		t.Error("HasPath")
+	}
+
+	for _, path := range []string{"a..b", "a.", ".a", "a[x]", "a[1", "a[01]", "a[0]b"} {
+		_, err := v.Path(path)
+		if !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestSetPointer(t *testing.T) {
+	v, _ := DecodeString(`{"a": {"items": [1, 2]}}`)
+	steps := []struct {
+		ptr   string
+		value interface{}
+	}{
+		{"/a/items/0", 10},
+		{"/a/items/-", 3},
+		{"/a/items/3", 4},
+		{"/a/name", "x"},
+	}
+	for _, step := range steps {
+		if err := v.SetPointer(step.ptr, step.value); err != nil {
+			t.Fatalf("%s: %v", step.ptr, err)
+		}
+	}
+	if err := v.SetPath("a.items[1]", 20); err != nil {
+		t.Fatal(err)
+	}
+	e := `{"a":{"items":[10,20,3,4],"name":"x"}}`
+	if s := marshalString(t, v.Value); s != e {
+		t.Errorf("%s != %s", s, e)
+	}
+
+	if err := v.SetPointer("/b/c", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("%v", err)
+	}
+	if err := v.SetPointer("/a/items/5", 1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("%v", err)
+	}
+
+	if err := v.SetPointer("", "root"); err != nil || v.Value != "root" {
+		t.Errorf("%v %v", err, v.Value)
+	}
+}
+
+func TestDeletePointer(t *testing.T) {
+	v, _ := DecodeString(`{"a": {"items": [1, 2, 3]}, "b": 1}`)
+	if err := v.DeletePointer("/a/items/1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.DeletePath("b"); err != nil {
+		t.Fatal(err)
+	}
+	e := `{"a":{"items":[1,3]}}`
+	if s := marshalString(t, v.Value); s != e {
+		t.Errorf("%s != %s", s, e)
+	}
+	if err := v.DeletePointer("/b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("%v", err)
+	}
+	if v.HasPointer("/b") || !v.HasPointer("/a/items/1") {
+		t.Error("HasPointer")
+	}
+}
@@ -0,0 +1,517 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema. It supports a subset of draft 2020-12:
+// type, enum, const, properties, additionalProperties, required,
+// prefixItems, items, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, minItems, maxItems, pattern, allOf, anyOf, oneOf,
+// not and $ref to a JSON Pointer in the same document, such as
+// "#/$defs/id". Other keywords are ignored. Patterns use the syntax of the
+// regexp package.
+type Schema struct {
+	root *schema
+}
+
+// SchemaError is a violation of a schema by the value at a JSON Pointer.
+type SchemaError struct {
+	Path    string
+	Keyword string
+	Msg     string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("jsonutil: %q: %s: %s", e.Path, e.Keyword, e.Msg)
+}
+
+// ValidationError holds all violations found by Schema.Validate.
+type ValidationError struct {
+	Errors []*SchemaError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+type schema struct {
+	ptr string // location in the schema document, for $ref
+
+	always *bool // for the schemas true and false
+
+	types      []string
+	enum       []interface{}
+	constant   interface{}
+	hasConst   bool
+	properties map[string]*schema
+	additional *schema
+	required   []string
+
+	prefixItems []*schema
+	items       *schema
+
+	minimum, maximum                   interface{}
+	exclusiveMinimum, exclusiveMaximum interface{}
+	minLength, maxLength               int
+	minItems, maxItems                 int
+	pattern                            *regexp.Regexp
+
+	allOf, anyOf, oneOf []*schema
+	not                 *schema
+
+	ref    string
+	target *schema
+}
+
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// CompileSchema compiles a JSON Schema document.
+func CompileSchema(doc *Value) (*Schema, error) {
+	c := &schemaCompiler{doc: unwrap(doc.Value), nodes: make(map[string]*schema)}
+	root, err := c.compile("", c.doc)
+	if err != nil {
+		return nil, err
+	}
+	// resolve references after all nodes are compiled, so that a schema
+	// may refer to itself
+	for i := 0; i < len(c.refs); i++ {
+		s := c.refs[i]
+		if s.target, err = c.resolve(s); err != nil {
+			return nil, err
+		}
+	}
+	return &Schema{root: root}, nil
+}
+
+// MustCompileSchema is like CompileSchema but parses a string and panics if
+// the schema is invalid.
+func MustCompileSchema(s string) *Schema {
+	v, err := DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	schema, err := CompileSchema(v)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+type schemaCompiler struct {
+	doc   interface{}
+	nodes map[string]*schema
+	refs  []*schema
+}
+
+func (c *schemaCompiler) errorf(ptr string, format string, args ...interface{}) error {
+	return fmt.Errorf("jsonutil: invalid schema at %q: %s", ptr, fmt.Sprintf(format, args...))
+}
+
+func (c *schemaCompiler) compile(ptr string, v interface{}) (*schema, error) {
+	if s, ok := c.nodes[ptr]; ok {
+		return s, nil
+	}
+	s := &schema{ptr: ptr, minLength: -1, maxLength: -1, minItems: -1, maxItems: -1}
+	c.nodes[ptr] = s
+	switch d := unwrap(v).(type) {
+	case bool:
+		s.always = &d
+		return s, nil
+	case map[string]interface{}:
+		for _, k := range sortedKeys(d) {
+			if err := c.keyword(s, k, unwrap(d[k])); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+	return nil, c.errorf(ptr, "schema must be an object or a boolean")
+}
+
+// keyword compiles the keyword k of value v into s.
+func (c *schemaCompiler) keyword(s *schema, k string, v interface{}) error {
+	ptr := s.ptr + "/" + pointerToken(k)
+	var err error
+	switch k {
+	case "type":
+		switch d := v.(type) {
+		case string:
+			s.types = []string{d}
+		case []interface{}:
+			for _, t := range d {
+				name, _ := t.(string)
+				s.types = append(s.types, name)
+			}
+		}
+		if len(s.types) == 0 {
+			return c.errorf(ptr, "type must be a string or an array of strings")
+		}
+		for _, t := range s.types {
+			if !schemaTypes[t] {
+				return c.errorf(ptr, "unknown type %q", t)
+			}
+		}
+	case "enum":
+		d, ok := v.([]interface{})
+		if !ok {
+			return c.errorf(ptr, "enum must be an array")
+		}
+		s.enum = d
+	case "const":
+		s.constant, s.hasConst = v, true
+	case "properties":
+		d, ok := v.(map[string]interface{})
+		if !ok {
+			return c.errorf(ptr, "properties must be an object")
+		}
+		s.properties = make(map[string]*schema, len(d))
+		for name, p := range d {
+			if s.properties[name], err = c.compile(ptr+"/"+pointerToken(name), p); err != nil {
+				return err
+			}
+		}
+	case "additionalProperties":
+		s.additional, err = c.compile(ptr, v)
+	case "required":
+		d, ok := v.([]interface{})
+		if !ok {
+			return c.errorf(ptr, "required must be an array of strings")
+		}
+		for _, name := range d {
+			n, ok := name.(string)
+			if !ok {
+				return c.errorf(ptr, "required must be an array of strings")
+			}
+			s.required = append(s.required, n)
+		}
+	case "prefixItems":
+		s.prefixItems, err = c.compileList(ptr, v)
+	case "items":
+		s.items, err = c.compile(ptr, v)
+	case "minimum":
+		s.minimum, err = c.number(ptr, v)
+	case "maximum":
+		s.maximum, err = c.number(ptr, v)
+	case "exclusiveMinimum":
+		s.exclusiveMinimum, err = c.number(ptr, v)
+	case "exclusiveMaximum":
+		s.exclusiveMaximum, err = c.number(ptr, v)
+	case "minLength":
+		s.minLength, err = c.count(ptr, v)
+	case "maxLength":
+		s.maxLength, err = c.count(ptr, v)
+	case "minItems":
+		s.minItems, err = c.count(ptr, v)
+	case "maxItems":
+		s.maxItems, err = c.count(ptr, v)
+	case "pattern":
+		d, ok := v.(string)
+		if !ok {
+			return c.errorf(ptr, "pattern must be a string")
+		}
+		if s.pattern, err = regexp.Compile(d); err != nil {
+			return c.errorf(ptr, "%v", err)
+		}
+	case "allOf":
+		s.allOf, err = c.compileList(ptr, v)
+	case "anyOf":
+		s.anyOf, err = c.compileList(ptr, v)
+	case "oneOf":
+		s.oneOf, err = c.compileList(ptr, v)
+	case "not":
+		s.not, err = c.compile(ptr, v)
+	case "$ref":
+		d, ok := v.(string)
+		if !ok || !strings.HasPrefix(d, "#") {
+			return c.errorf(ptr, "$ref must refer to the same document, such as \"#/$defs/a\"")
+		}
+		s.ref = d
+		c.refs = append(c.refs, s)
+	}
+	return err
+}
+
+// compileList compiles a non-empty array of schemas.
+func (c *schemaCompiler) compileList(ptr string, v interface{}) ([]*schema, error) {
+	d, ok := v.([]interface{})
+	if !ok || len(d) == 0 {
+		return nil, c.errorf(ptr, "must be a non-empty array of schemas")
+	}
+	list := make([]*schema, len(d))
+	for i, e := range d {
+		s, err := c.compile(fmt.Sprintf("%s/%d", ptr, i), e)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = s
+	}
+	return list, nil
+}
+
+func (c *schemaCompiler) number(ptr string, v interface{}) (interface{}, error) {
+	if _, ok := toNumber(v); !ok {
+		return nil, c.errorf(ptr, "must be a number")
+	}
+	return v, nil
+}
+
+func (c *schemaCompiler) count(ptr string, v interface{}) (int, error) {
+	r, err := toRat(v)
+	if _, ok := toNumber(v); !ok || err != nil || !r.IsInt() || r.Sign() < 0 || !r.Num().IsInt64() {
+		return 0, c.errorf(ptr, "must be a non-negative integer")
+	}
+	return int(r.Num().Int64()), nil
+}
+
+// resolve returns the schema that the $ref of s refers to.
+func (c *schemaCompiler) resolve(s *schema) (*schema, error) {
+	p, err := parsePointer(s.ref[1:])
+	if err != nil {
+		return nil, c.errorf(s.ptr, "invalid $ref %q", s.ref)
+	}
+	v, err := p.get(c.doc)
+	if err != nil {
+		return nil, c.errorf(s.ptr, "$ref %q not found", s.ref)
+	}
+	return c.compile(s.ref[1:], v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate validates v against the schema and returns a *ValidationError
+// with all violations, or nil if v is valid.
+func (s *Schema) Validate(v *Value) error {
+	val := &validator{active: make(map[string]bool)}
+	val.validate(s.root, "", unwrap(v.Value))
+	if len(val.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: val.errors}
+}
+
+type validator struct {
+	errors []*SchemaError
+	// active holds the $ref targets being applied to an instance, to stop
+	// a schema that refers to itself without consuming the instance
+	active map[string]bool
+}
+
+func (val *validator) errorf(ptr, keyword, format string, args ...interface{}) {
+	val.errors = append(val.errors, &SchemaError{Path: ptr, Keyword: keyword, Msg: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether v is valid against s without recording errors.
+func (val *validator) valid(s *schema, ptr string, v interface{}) bool {
+	sub := &validator{active: val.active}
+	sub.validate(s, ptr, v)
+	return len(sub.errors) == 0
+}
+
+func (val *validator) validate(s *schema, ptr string, v interface{}) {
+	if s.always != nil {
+		if !*s.always {
+			val.errorf(ptr, "false", "no value is allowed")
+		}
+		return
+	}
+
+	if s.target != nil {
+		key := s.target.ptr + "\x00" + ptr
+		if val.active[key] {
+			val.errorf(ptr, "$ref", "%q refers to itself", s.ref)
+		} else {
+			val.active[key] = true
+			val.validate(s.target, ptr, v)
+			delete(val.active, key)
+		}
+	}
+
+	if len(s.types) > 0 && !matchType(s.types, v) {
+		val.errorf(ptr, "type", "%s is not %s", typeName(v), strings.Join(s.types, " or "))
+	}
+	if s.enum != nil {
+		found := false
+		for _, e := range s.enum {
+			if jsonEqual(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			val.errorf(ptr, "enum", "value is not one of %s", marshalText(s.enum))
+		}
+	}
+	if s.hasConst && !jsonEqual(v, s.constant) {
+		val.errorf(ptr, "const", "value is not %s", marshalText(s.constant))
+	}
+
+	switch d := v.(type) {
+	case map[string]interface{}:
+		val.validateObject(s, ptr, d)
+	case []interface{}:
+		val.validateArray(s, ptr, d)
+	case string:
+		n := utf8.RuneCountInString(d)
+		if s.minLength >= 0 && n < s.minLength {
+			val.errorf(ptr, "minLength", "length %d is less than %d", n, s.minLength)
+		}
+		if s.maxLength >= 0 && n > s.maxLength {
+			val.errorf(ptr, "maxLength", "length %d is greater than %d", n, s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(d) {
+			val.errorf(ptr, "pattern", "%q does not match %q", d, s.pattern)
+		}
+	default:
+		if _, ok := toNumber(d); ok {
+			val.validateNumber(s, ptr, d)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		val.validate(sub, ptr, v)
+	}
+	if s.anyOf != nil {
+		found := false
+		for _, sub := range s.anyOf {
+			if val.valid(sub, ptr, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			val.errorf(ptr, "anyOf", "value does not match any schema")
+		}
+	}
+	if s.oneOf != nil {
+		n := 0
+		for _, sub := range s.oneOf {
+			if val.valid(sub, ptr, v) {
+				n++
+			}
+		}
+		if n != 1 {
+			val.errorf(ptr, "oneOf", "value matches %d schemas instead of one", n)
+		}
+	}
+	if s.not != nil && val.valid(s.not, ptr, v) {
+		val.errorf(ptr, "not", "value matches the schema")
+	}
+}
+
+func (val *validator) validateObject(s *schema, ptr string, m map[string]interface{}) {
+	for _, name := range s.required {
+		if _, ok := m[name]; !ok {
+			val.errorf(ptr, "required", "%q is missing", name)
+		}
+	}
+	for _, k := range sortedKeys(m) {
+		p := ptr + "/" + pointerToken(k)
+		if sub, ok := s.properties[k]; ok {
+			val.validate(sub, p, unwrap(m[k]))
+		} else if s.additional != nil {
+			val.validate(s.additional, p, unwrap(m[k]))
+		}
+	}
+}
+
+func (val *validator) validateArray(s *schema, ptr string, a []interface{}) {
+	if s.minItems >= 0 && len(a) < s.minItems {
+		val.errorf(ptr, "minItems", "%d items are less than %d", len(a), s.minItems)
+	}
+	if s.maxItems >= 0 && len(a) > s.maxItems {
+		val.errorf(ptr, "maxItems", "%d items are more than %d", len(a), s.maxItems)
+	}
+	for i, e := range a {
+		p := fmt.Sprintf("%s/%d", ptr, i)
+		if i < len(s.prefixItems) {
+			val.validate(s.prefixItems[i], p, unwrap(e))
+		} else if s.items != nil {
+			val.validate(s.items, p, unwrap(e))
+		}
+	}
+}
+
+func (val *validator) validateNumber(s *schema, ptr string, v interface{}) {
+	if c, ok := compareNumbers(v, s.minimum); ok && c < 0 {
+		val.errorf(ptr, "minimum", "%s is less than %s", marshalText(v), marshalText(s.minimum))
+	}
+	if c, ok := compareNumbers(v, s.maximum); ok && c > 0 {
+		val.errorf(ptr, "maximum", "%s is greater than %s", marshalText(v), marshalText(s.maximum))
+	}
+	if c, ok := compareNumbers(v, s.exclusiveMinimum); ok && c <= 0 {
+		val.errorf(ptr, "exclusiveMinimum", "%s is not greater than %s", marshalText(v), marshalText(s.exclusiveMinimum))
+	}
+	if c, ok := compareNumbers(v, s.exclusiveMaximum); ok && c >= 0 {
+		val.errorf(ptr, "exclusiveMaximum", "%s is not less than %s", marshalText(v), marshalText(s.exclusiveMaximum))
+	}
+}
+
+func matchType(types []string, v interface{}) bool {
+	name := typeName(v)
+	for _, t := range types {
+		if t == name || (t == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON Schema type of v. Numbers without a fraction,
+// such as 1.0, are integers.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := toNumber(v); ok {
+		if r, err := toRat(v); err == nil && r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func marshalText(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
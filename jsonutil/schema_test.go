@@ -0,0 +1,162 @@
+package jsonutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"$defs": {
+		"id": {"type": "integer", "minimum": 1},
+		"node": {
+			"type": "object",
+			"properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}},
+			"required": ["name"]
+		}
+	},
+	"type": "object",
+	"properties": {
+		"id": {"$ref": "#/$defs/id"},
+		"name": {"type": "string", "minLength": 1, "maxLength": 5, "pattern": "^[a-z]+$"},
+		"kind": {"enum": ["a", "b"]},
+		"version": {"const": 2},
+		"score": {"type": "number", "exclusiveMinimum": 0, "maximum": 1.5},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+		"point": {"prefixItems": [{"type": "number"}, {"type": "number"}], "items": false},
+		"value": {"oneOf": [{"type": "integer"}, {"type": "number", "maximum": 10}]},
+		"ref": {"anyOf": [{"type": "null"}, {"$ref": "#/$defs/id"}]},
+		"tree": {"$ref": "#/$defs/node"},
+		"secret": {"not": {"type": "string"}},
+		"all": {"allOf": [{"type": ["string", "null"]}, {"maxLength": 2}]},
+		"a/b": {"type": "boolean"}
+	},
+	"required": ["id", "name"],
+	"additionalProperties": false
+}`
+
+func TestSchema(t *testing.T) {
+	s := MustCompileSchema(testSchema)
+
+	valid := `{
+		"id": 9007199254740993, "name": "abc", "kind": "a", "version": 2.0,
+		"score": 1.5, "tags": ["x"], "point": [1, 2.5], "value": 3.5, "ref": null,
+		"tree": {"name": "r", "children": [{"name": "c", "children": []}]},
+		"secret": 1, "all": "ab", "a/b": true
+	}`
+	if err := s.Validate(mustDecode(t, valid)); err != nil {
+		t.Error(err)
+	}
+
+	invalid := `{
+		"id": 0, "kind": "c", "version": 3, "score": 0, "tags": [], "point": [1, 2, 3],
+		"value": 20.5, "ref": 1.5, "tree": {"children": [{"children": [{}]}]},
+		"secret": "s", "all": "abc", "a/b": 1, "extra": 1
+	}`
+	err := s.Validate(mustDecode(t, invalid))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("%v", err)
+	}
+	var got []string
+	for _, e := range ve.Errors {
+		got = append(got, e.Path+" "+e.Keyword)
+	}
+	e := []string{
+		` required`,
+		`/a~1b type`,
+		`/all maxLength`,
+		`/extra false`,
+		`/id minimum`,
+		`/kind enum`,
+		`/point/2 false`,
+		`/ref anyOf`,
+		`/score exclusiveMinimum`,
+		`/secret not`,
+		`/tags minItems`,
+		`/tree required`,
+		`/tree/children/0 required`,
+		`/tree/children/0/children/0 required`,
+		`/value oneOf`,
+		`/version const`,
+	}
+	if strings.Join(got, "\n") != strings.Join(e, "\n") {
+		t.Errorf("%s\n!=\n%s", strings.Join(got, "\n"), strings.Join(e, "\n"))
+	}
+
+	var se *SchemaError
+	if !errors.As(err, &se) || se.Path != "" || se.Msg != `"name" is missing` {
+		t.Errorf("%v", se)
+	}
+
+	err = s.Validate(mustDecode(t, `{"id": 1, "name": "ABCDEF"}`))
+	if err == nil || !strings.Contains(err.Error(), `"/name": maxLength: length 6 is greater than 5`) ||
+		!strings.Contains(err.Error(), `"/name": pattern: "ABCDEF" does not match "^[a-z]+$"`) {
+		t.Errorf("%v", err)
+	}
+
+	err = s.Validate(mustDecode(t, `[]`))
+	if err == nil || err.Error() != `jsonutil: "": type: array is not object` {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestSchemaRef(t *testing.T) {
+	s := MustCompileSchema(`{"anyOf": [{"type": "integer"}, {"type": "array", "items": {"$ref": "#"}}]}`)
+	if err := s.Validate(mustDecode(t, `[1, [2, [3]]]`)); err != nil {
+		t.Error(err)
+	}
+	if err := s.Validate(mustDecode(t, `[1, ["x"]]`)); err == nil {
+		t.Error("no error")
+	}
+
+	s = MustCompileSchema(`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`)
+	if err := s.Validate(mustDecode(t, `1`)); err == nil || !strings.Contains(err.Error(), "refers to itself") {
+		t.Errorf("%v", err)
+	}
+
+	if err := MustCompileSchema(`true`).Validate(mustDecode(t, `1`)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompileSchemaError(t *testing.T) {
+	tests := []struct {
+		s string
+		e string
+	}{
+		{`1`, `""`},
+		{`{"type": "int"}`, `"/type"`},
+		{`{"properties": {"a": {"minimum": "1"}}}`, `"/properties/a/minimum"`},
+		{`{"minLength": -1}`, `"/minLength"`},
+		{`{"pattern": "("}`, `"/pattern"`},
+		{`{"oneOf": []}`, `"/oneOf"`},
+		{`{"items": {"$ref": "#/$defs/x"}}`, `"/items"`},
+		{`{"$ref": "other.json#/a"}`, `"/$ref"`},
+		{`{"required": [1]}`, `"/required"`},
+	}
+	for _, test := range tests {
+		_, err := CompileSchema(mustDecode(t, test.s))
+		if err == nil || !strings.Contains(err.Error(), "invalid schema at "+test.e) {
+			t.Errorf("%s: %v", test.s, err)
+		}
+	}
+}
+
+func TestSchemaDecimal(t *testing.T) {
+	s := MustCompileSchema(`{"properties": {
+		"a": {"enum": [9.99, 0.1]},
+		"b": {"const": 0.1},
+		"c": {"maximum": 0.1, "exclusiveMinimum": 0}
+	}}`)
+	v := &Value{map[string]interface{}{"a": 9.99, "b": 0.1, "c": 0.1}}
+	if err := s.Validate(v); err != nil {
+		t.Error(err)
+	}
+	if err := s.Validate(mustDecode(t, `{"a": 0.1, "b": 0.1, "c": 0.1}`)); err != nil {
+		t.Error(err)
+	}
+	if err := s.Validate(mustDecode(t, `{"a": 0.2, "b": 0.10000000000000001, "c": 0.2}`)); err == nil {
+		t.Error("no error")
+	}
+}
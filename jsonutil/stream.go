@@ -0,0 +1,246 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// StreamError records the byte offset in the input at which a Stream
+// failed.
+type StreamError struct {
+	Offset int64 // for a syntax error, the bytes read up to the bad one
+	Err    error
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("jsonutil: offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Stream iterates the elements of an array or the members of an object in a
+// JSON document without decoding the whole document, or the values of a
+// JSON Lines document. Only one element is held in memory at a time.
+//
+//	s, _ := jsonutil.NewStream(r, "/items")
+//	for s.Next() {
+//		var item Item
+//		if err := s.Decode(&item); err != nil {
+//			return err
+//		}
+//	}
+//	if err := s.Err(); err != nil {
+//		return err
+//	}
+type Stream struct {
+	dec    *json.Decoder
+	path   *path
+	lines  bool
+	object bool
+
+	started bool
+	done    bool
+	err     error
+
+	key    string
+	index  int
+	offset int64
+	raw    json.RawMessage
+}
+
+// NewStream returns a Stream over the array or object at a JSON Pointer in
+// r. The empty pointer refers to the document itself.
+func NewStream(r io.Reader, ptr string) (*Stream, error) {
+	p, err := parsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{dec: json.NewDecoder(r), path: p, index: -1}, nil
+}
+
+// NewLineStream returns a Stream over the values of a JSON Lines (NDJSON)
+// document in r.
+func NewLineStream(r io.Reader) *Stream {
+	return &Stream{dec: json.NewDecoder(r), lines: true, index: -1}
+}
+
+// Next reads the next element, returning false at the end of the array,
+// object or input or on an error.
+func (s *Stream) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		if !s.lines {
+			if s.err = s.seek(); s.err != nil {
+				return false
+			}
+		}
+	}
+	s.raw = nil
+	if !s.dec.More() {
+		s.done = true
+		// consume the end of the array or object, or report a stray one in
+		// JSON Lines
+		if _, err := s.dec.Token(); err != nil && !(s.lines && err == io.EOF) {
+			s.err = s.error(err)
+		}
+		return false
+	}
+	if s.object {
+		tok, err := s.dec.Token()
+		if err != nil {
+			s.err = s.error(err)
+			return false
+		}
+		s.key = tok.(string)
+	}
+	if err := s.dec.Decode(&s.raw); err != nil {
+		s.err = s.error(err)
+		return false
+	}
+	s.index++
+	s.offset = s.dec.InputOffset() - int64(len(s.raw))
+	return true
+}
+
+// seek reads the input up to the array or object at the path of s.
+func (s *Stream) seek() error {
+	for pos := 0; ; pos++ {
+		offset := s.dec.InputOffset()
+		tok, err := s.dec.Token()
+		if err != nil {
+			return s.error(err)
+		}
+		d, ok := tok.(json.Delim)
+		if !ok {
+			// the value at the last segment is not an array or object
+			return &StreamError{Offset: offset, Err: s.path.error(max(pos-1, 0), ErrInvalidType)}
+		}
+		if pos == len(s.path.segs) {
+			s.object = d == '{'
+			return nil
+		}
+		if err := s.seekChild(pos, d); err != nil {
+			return err
+		}
+	}
+}
+
+// seekChild skips the members or elements of a container before the one of
+// segment pos.
+func (s *Stream) seekChild(pos int, d json.Delim) error {
+	seg := s.path.segs[pos]
+	n := -1
+	if d == '[' {
+		if !isIndex(seg.key) {
+			return &StreamError{Offset: s.dec.InputOffset(), Err: s.path.error(pos, ErrInvalidPath)}
+		}
+		n, _ = strconv.Atoi(seg.key)
+	}
+	for i := 0; ; i++ {
+		if !s.dec.More() {
+			err := ErrNotFound
+			if d == '[' {
+				err = ErrOutOfRange
+			}
+			return &StreamError{Offset: s.dec.InputOffset(), Err: s.path.error(pos, err)}
+		}
+		if d == '{' {
+			tok, err := s.dec.Token()
+			if err != nil {
+				return s.error(err)
+			}
+			if tok.(string) == seg.key {
+				return nil
+			}
+		} else if i == n {
+			return nil
+		}
+		if err := s.skip(); err != nil {
+			return err
+		}
+	}
+}
+
+// skip reads a value token by token, so that a large value is not held in
+// memory.
+func (s *Stream) skip() error {
+	depth := 0
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return s.error(err)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// error returns err with the offset in the input at which it occurred.
+func (s *Stream) error(err error) error {
+	offset := s.dec.InputOffset()
+	if e, ok := err.(*json.SyntaxError); ok {
+		offset = e.Offset
+	}
+	return &StreamError{Offset: offset, Err: err}
+}
+
+// Key returns the member name of the current element of an object.
+func (s *Stream) Key() string {
+	return s.key
+}
+
+// Index returns the index of the current element, counting from zero.
+func (s *Stream) Index() int {
+	return s.index
+}
+
+// Offset returns the byte offset of the current element in the input.
+func (s *Stream) Offset() int64 {
+	return s.offset
+}
+
+// Value decodes the current element with numbers as json.Number.
+func (s *Stream) Value() (*Value, error) {
+	if s.raw == nil {
+		return nil, ErrNotFound
+	}
+	v := &Value{}
+	if err := v.UnmarshalJSON(s.raw); err != nil {
+		return nil, &StreamError{Offset: s.offset, Err: err}
+	}
+	return v, nil
+}
+
+// Decode decodes the current element into v as json.Unmarshal does.
+func (s *Stream) Decode(v interface{}) error {
+	if s.raw == nil {
+		return ErrNotFound
+	}
+	if err := json.Unmarshal(s.raw, v); err != nil {
+		offset := s.offset
+		if e, ok := err.(*json.UnmarshalTypeError); ok {
+			offset += e.Offset
+		}
+		return &StreamError{Offset: offset, Err: err}
+	}
+	return nil
+}
+
+// Err returns the error that stopped Next, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
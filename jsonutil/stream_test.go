@@ -0,0 +1,131 @@
+package jsonutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	doc := `{"meta": {"skip": [1, {"a": "]}"}]}, "items": [{"id": 1}, {"id": 2}, {"id": 3}], "names": {"a": "x", "b": "y"}}`
+	s, err := NewStream(strings.NewReader(doc), "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type item struct {
+		ID int `json:"id"`
+	}
+	var ids []int
+	for s.Next() {
+		var it item
+		if err := s.Decode(&it); err != nil {
+			t.Fatal(err)
+		}
+		if s.Index() != len(ids) {
+			t.Errorf("%d != %d", s.Index(), len(ids))
+		}
+		if e := `{"id": `; doc[s.Offset():s.Offset()+int64(len(e))] != e {
+			t.Errorf("offset %d", s.Offset())
+		}
+		ids = append(ids, it.ID)
+	}
+	if s.Err() != nil {
+		t.Error(s.Err())
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("%v", ids)
+	}
+
+	s, _ = NewStream(strings.NewReader(doc), "/names")
+	var kv []string
+	for s.Next() {
+		v, err := s.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		str, _ := v.String()
+		kv = append(kv, s.Key()+"="+str)
+	}
+	if strings.Join(kv, ",") != "a=x,b=y" || s.Err() != nil {
+		t.Errorf("%v %v", kv, s.Err())
+	}
+
+	s, _ = NewStream(strings.NewReader(doc), "/meta/skip/1")
+	if !s.Next() || s.Key() != "a" || s.Next() || s.Err() != nil {
+		t.Errorf("%s %v", s.Key(), s.Err())
+	}
+
+	s, _ = NewStream(strings.NewReader(`[]`), "")
+	if s.Next() || s.Err() != nil {
+		t.Errorf("%v", s.Err())
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	tests := []struct {
+		doc    string
+		ptr    string
+		err    error
+		offset int64
+	}{
+		{`{"a": [1, 2]}`, "/b", ErrNotFound, 12},
+		{`{"a": [1, 2]}`, "/a/2", ErrOutOfRange, 11},
+		{`{"a": [1, 2]}`, "/a/0", ErrInvalidType, 7},
+		{`{"a": 1}`, "/a", ErrInvalidType, 4},
+		{`[1, 2 3]`, "", nil, 7},
+		{`[1, {"a" 1}]`, "", nil, 10},
+		{`[1, 2, tru]`, "", nil, 11},
+		{`[1, 2`, "", nil, 5},
+	}
+	for _, test := range tests {
+		s, _ := NewStream(strings.NewReader(test.doc), test.ptr)
+		for s.Next() {
+		}
+		var se *StreamError
+		if !errors.As(s.Err(), &se) || se.Offset != test.offset || (test.err != nil && !errors.Is(se, test.err)) {
+			t.Errorf("%s %s: %v", test.doc, test.ptr, s.Err())
+		}
+	}
+
+	s, _ := NewStream(strings.NewReader(`[{"id": 1}, {"id": "x"}]`), "")
+	type item struct {
+		ID int `json:"id"`
+	}
+	var it item
+	s.Next()
+	s.Next()
+	var se *StreamError
+	if err := s.Decode(&it); !errors.As(err, &se) || se.Offset != 22 {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestLineStream(t *testing.T) {
+	s := NewLineStream(strings.NewReader("{\"n\": 1}\n{\"n\": 2}\n\n[3]\n"))
+	var lines []string
+	for s.Next() {
+		v, err := s.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, marshalString(t, v.Value))
+	}
+	if e := `{"n":1} {"n":2} [3]`; strings.Join(lines, " ") != e || s.Err() != nil {
+		t.Errorf("%v != %s: %v", lines, e, s.Err())
+	}
+
+	s = NewLineStream(strings.NewReader("{\"n\": 1}\n{\"n\": }\n"))
+	for s.Next() {
+	}
+	var se *StreamError
+	if !errors.As(s.Err(), &se) || se.Offset != 16 || s.Index() != 0 {
+		t.Errorf("%v", s.Err())
+	}
+
+	s = NewLineStream(strings.NewReader("1\n]\n"))
+	for s.Next() {
+	}
+	if !errors.As(s.Err(), &se) || se.Offset != 3 {
+		t.Errorf("%v", s.Err())
+	}
+}
@@ -1,19 +1,24 @@
 package logv
 
 import (
+	"fmt"
 	"io"
-	"log"
-	"os"
+	"strings"
+
+	"github.com/najeira/goutils/nlog"
 )
 
 const (
-	No = iota
-	Fatal
-	Err
-	Warn
-	Info
-	Debug
-	Trace
+	No    = nlog.No
+	Fatal = nlog.Fatal
+	Err   = nlog.Error
+	Warn  = nlog.Warn
+	Info  = nlog.Info
+	Debug = nlog.Debug
+	Trace = nlog.Trace
+
+	// Error is the nlog name of Err.
+	Error = Err
 )
 
 type Logger interface {
@@ -25,77 +30,91 @@ type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+// logger writes through an nlog.Core, so that the output, level and format
+// of an nlog logger on the same core apply to it too.
 type logger struct {
-	logger *log.Logger
-	level  int
+	core *nlog.Core
 }
 
 var (
-	_             Logger = (*logger)(nil)
-	defaultLogger Logger
-)
+	_ Logger = (*logger)(nil)
 
-func init() {
-	defaultLogger = NewLogger()
-}
+	// defaultLogger shares nlog.DefaultCore.
+	defaultLogger = &logger{core: nlog.DefaultCore()}
+)
 
 func SetOutput(out io.Writer) {
 	defaultLogger.SetOutput(out)
 }
 
+// SetLevel sets the level of the default logger, which is shared with
+// nlog. The level is nlog.DefaultLevel (Info) by default; it was Warn
+// before logv moved onto nlog's core, so call SetLevel(Warn) to keep V(Info)
+// false.
 func SetLevel(level int) {
 	defaultLogger.SetLevel(level)
 }
 
+// the package functions call the core directly to keep the call depth of
+// the logger methods.
+
 func V(level int) bool {
-	return defaultLogger.V(level)
+	return defaultLogger.core.Enabled(level, 2)
 }
 
 func Print(v ...interface{}) {
-	defaultLogger.Print(v...)
+	defaultLogger.core.Output(2, No, fmt.Sprint(v...))
 }
 
 func Println(v ...interface{}) {
-	defaultLogger.Println(v...)
+	defaultLogger.core.Output(2, No, sprintln(v...))
 }
 
 func Printf(format string, v ...interface{}) {
-	defaultLogger.Printf(format, v...)
+	defaultLogger.core.Output(2, No, fmt.Sprintf(format, v...))
 }
 
+// NewLogger returns a Logger with its own core at nlog.DefaultLevel (Info)
+// and LstdFlags. The level used to be Warn, so V(Info) is now true by
+// default; call SetLevel(Warn) to restore the former behavior.
 func NewLogger() Logger {
-	return &logger{
-		logger: log.New(os.Stdout, "", log.LstdFlags),
-		level:  Warn,
-	}
+	return NewLoggerWithCore(nlog.NewCore(&nlog.Config{
+		Level: nlog.DefaultLevel,
+		Flag:  nlog.LstdFlags,
+	}))
+}
+
+// NewLoggerWithCore returns a Logger writing through core.
+func NewLoggerWithCore(core *nlog.Core) Logger {
+	return &logger{core: core}
 }
 
 func (l *logger) SetOutput(out io.Writer) {
-	l.logger = log.New(out, "", log.LstdFlags)
+	l.core.SetOutput(out)
 }
 
 func (l *logger) SetLevel(level int) {
-	l.level = level
+	l.core.SetLevel(level)
 }
 
 func (l *logger) V(level int) bool {
-	return level <= l.level && level > No
+	return l.core.Enabled(level, 2)
 }
 
 func (l *logger) Print(v ...interface{}) {
-	if l.logger != nil {
-		l.logger.Print(v)
-	}
+	l.core.Output(2, No, fmt.Sprint(v...))
 }
 
 func (l *logger) Println(v ...interface{}) {
-	if l.logger != nil {
-		l.logger.Println(v)
-	}
+	l.core.Output(2, No, sprintln(v...))
 }
 
 func (l *logger) Printf(format string, v ...interface{}) {
-	if l.logger != nil {
-		l.logger.Printf(format, v...)
-	}
+	l.core.Output(2, No, fmt.Sprintf(format, v...))
+}
+
+// sprintln formats as fmt.Sprintln without the newline, which is added
+// when the record is written.
+func sprintln(v ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(v...), "\n")
 }
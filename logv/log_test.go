@@ -0,0 +1,92 @@
+package logv
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+func newTestLogger() (Logger, *nlog.Core, *bytes.Buffer) {
+	var buf bytes.Buffer
+	core := nlog.NewCore(&nlog.Config{Out: &buf, Level: Info})
+	return NewLoggerWithCore(core), core, &buf
+}
+
+func TestPrint(t *testing.T) {
+	l, _, buf := newTestLogger()
+	tests := []struct {
+		print func()
+		e     string
+	}{
+		{func() { l.Print("a", "b") }, "ab\n"},
+		{func() { l.Print(1, 2) }, "1 2\n"},
+		{func() { l.Println("a", "b") }, "a b\n"},
+		{func() { l.Printf("%s=%d", "a", 1) }, "a=1\n"},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		test.print()
+		if buf.String() != test.e {
+			t.Errorf("%q != %q", buf.String(), test.e)
+		}
+	}
+}
+
+func TestSharedCore(t *testing.T) {
+	l, core, _ := newTestLogger()
+	nl := core.Logger()
+
+	var out bytes.Buffer
+	l.SetOutput(&out)
+	nl.Info("from nlog")
+	l.Print("from logv")
+	if e := "[INFO] from nlog\nfrom logv\n"; out.String() != e {
+		t.Errorf("%q != %q", out.String(), e)
+	}
+
+	l.SetLevel(Warn)
+	if nl.V(Info) || !nl.V(Warn) {
+		t.Error("nlog does not see the level set by logv")
+	}
+	core.SetLevel(Debug)
+	if !l.V(Debug) || l.V(Trace) {
+		t.Error("logv does not see the level set on the core")
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out)
+	defer SetOutput(os.Stdout)
+	nlog.DefaultCore().SetFlags(0)
+	defer nlog.DefaultCore().SetFlags(nlog.LstdFlags)
+
+	Print("a", "b")
+	nlog.DefaultCore().Logger().Warn("c")
+	if e := "ab\n[WARN] c\n"; out.String() != e {
+		t.Errorf("%q != %q", out.String(), e)
+	}
+}
+
+func TestErrAlias(t *testing.T) {
+	if Err != nlog.Error || Error != Err {
+		t.Errorf("%d != %d", Err, nlog.Error)
+	}
+	l, _, _ := newTestLogger()
+	l.SetLevel(Err)
+	if !l.V(Error) || l.V(Warn) {
+		t.Error("Err is not the level of errors")
+	}
+}
+
+func TestDefaultLevel(t *testing.T) {
+	l := NewLogger()
+	if !l.V(Info) || l.V(Debug) {
+		t.Error("the default level is not Info")
+	}
+	if !V(Info) || V(Debug) {
+		t.Error("the default level of the package is not Info")
+	}
+}
@@ -0,0 +1,129 @@
+package logv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+// SlogLevel maps a level to the slog level scale. See nlog.SlogLevel.
+func SlogLevel(level int) slog.Level {
+	return nlog.SlogLevel(level)
+}
+
+// FromSlogLevel maps a slog level to a level. See nlog.FromSlogLevel.
+func FromSlogLevel(level slog.Level) int {
+	return nlog.FromSlogLevel(level)
+}
+
+type slogHandler struct {
+	slog.Handler
+	l Logger
+}
+
+// NewSlogHandler returns an slog.Handler that writes records through l,
+// formatted as "[LEVEL] message key=value ...". Records are enabled when
+// l.V reports their level as enabled. A Logger of this package writes
+// records to its core with the call site of the slog call; other Loggers
+// receive them through Print.
+func NewSlogHandler(l Logger) slog.Handler {
+	if cl, ok := l.(*logger); ok {
+		return &slogHandler{Handler: nlog.NewSlogHandler(cl.core.Logger()), l: l}
+	}
+	nl := nlog.NewLogger(&nlog.Config{Out: printWriter{l}, Level: Trace})
+	return &slogHandler{Handler: nlog.NewSlogHandler(nl), l: l}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.V(FromSlogLevel(level))
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &slogHandler{Handler: h.Handler.WithAttrs(attrs), l: h.l}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{Handler: h.Handler.WithGroup(name), l: h.l}
+}
+
+// printWriter writes each line it receives through Logger.Print.
+type printWriter struct {
+	l Logger
+}
+
+func (w printWriter) Write(p []byte) (int, error) {
+	w.l.Print(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+type slogLogger struct {
+	h     slog.Handler
+	level int
+	mu    sync.RWMutex
+}
+
+var _ Logger = (*slogLogger)(nil)
+
+// NewSlogLogger returns a Logger that writes to an slog.Handler at
+// slog.LevelInfo. V reports a level as enabled when it is within the
+// logger's level, nlog.DefaultLevel by default, and the handler is enabled
+// for it.
+func NewSlogLogger(h slog.Handler) Logger {
+	return &slogLogger{h: h, level: nlog.DefaultLevel}
+}
+
+// SetOutput replaces the handler with an slog.TextHandler writing to out.
+func (l *slogLogger) SetOutput(out io.Writer) {
+	h := slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level:       nlog.LevelTrace,
+		ReplaceAttr: nlog.ReplaceLevelName,
+	})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.h = h
+}
+
+func (l *slogLogger) SetLevel(level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+func (l *slogLogger) V(level int) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return level <= l.level && level > No && l.h.Enabled(context.Background(), SlogLevel(level))
+}
+
+func (l *slogLogger) Print(v ...interface{}) {
+	l.output(fmt.Sprint(v...))
+}
+
+func (l *slogLogger) Println(v ...interface{}) {
+	l.output(sprintln(v...))
+}
+
+func (l *slogLogger) Printf(format string, v ...interface{}) {
+	l.output(fmt.Sprintf(format, v...))
+}
+
+func (l *slogLogger) output(msg string) {
+	l.mu.RLock()
+	h := l.h
+	l.mu.RUnlock()
+	ctx := context.Background()
+	if !h.Enabled(ctx, slog.LevelInfo) {
+		return
+	}
+	var pcs [1]uintptr
+	// skip runtime.Callers, output and the exported method
+	runtime.Callers(3, pcs[:])
+	h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, msg, pcs[0]))
+}
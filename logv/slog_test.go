@@ -0,0 +1,63 @@
+package logv
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithCore(nlog.NewCore(&nlog.Config{Out: &buf, Level: Trace, Flag: nlog.Lshortfile}))
+	sl := slog.New(NewSlogHandler(l))
+
+	_, _, line, _ := runtime.Caller(0)
+	sl.Log(context.Background(), nlog.LevelTrace, "trace", "a", 1)
+	sl.Log(context.Background(), nlog.LevelFatal, "fatal")
+	sl.With("b", 2).Info("info")
+
+	e := "slog_test.go:" + strconv.Itoa(line+1) + ": [TRACE] trace a=1\n" +
+		"slog_test.go:" + strconv.Itoa(line+2) + ": [FATAL] fatal\n" +
+		"slog_test.go:" + strconv.Itoa(line+3) + ": [INFO] info b=2\n"
+	if buf.String() != e {
+		t.Errorf("%q != %q", buf.String(), e)
+	}
+
+	buf.Reset()
+	l.SetLevel(Warn)
+	sl.Info("info")
+	if buf.Len() != 0 {
+		t.Errorf("%q", buf.String())
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: nlog.LevelTrace})
+	l := NewSlogLogger(h)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Print("a", "b")
+	re := regexp.MustCompile(`level=INFO source=\S*slog_test.go:` + strconv.Itoa(line+1) + ` msg=ab`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("%q does not match %s", buf.String(), re)
+	}
+
+	// the slog handler round-trips records back to a logv logger
+	var out bytes.Buffer
+	back := NewLoggerWithCore(nlog.NewCore(&nlog.Config{Out: &out, Level: Trace}))
+	l = NewSlogLogger(NewSlogHandler(back))
+	l.Print("c")
+	if out.String() != "[INFO] c\n" {
+		t.Errorf("%q", out.String())
+	}
+	if !l.V(Info) || l.V(Debug) {
+		t.Error("the default level is not Info")
+	}
+}
@@ -204,3 +204,78 @@ func (m *MetricsHttp) Get() map[string]float64 {
 		"5xx_rate":       m.status5xx.Rate1(),
 	}
 }
+
+type MetricsQueue struct {
+	depth       mt.Histogram
+	processed   mt.Meter
+	failures    mt.Meter
+	retries     mt.Meter
+	deadLetters mt.Meter
+	latency     mt.Timer
+}
+
+func NewMetricsQueue() *MetricsQueue {
+	return &MetricsQueue{
+		depth:       mt.NewHistogram(mt.NewExpDecaySample(1028, 0.015)),
+		processed:   mt.NewMeter(),
+		failures:    mt.NewMeter(),
+		retries:     mt.NewMeter(),
+		deadLetters: mt.NewMeter(),
+		latency:     mt.NewTimer(),
+	}
+}
+
+func (m *MetricsQueue) UpdateDepth(v int) {
+	m.depth.Update(int64(v))
+}
+
+func (m *MetricsQueue) MarkProcessed(v int) {
+	if v != 0 {
+		m.processed.Mark(int64(v))
+	}
+}
+
+func (m *MetricsQueue) MarkFailures(v int) {
+	if v != 0 {
+		m.failures.Mark(int64(v))
+	}
+}
+
+func (m *MetricsQueue) MarkRetries(v int) {
+	if v != 0 {
+		m.retries.Mark(int64(v))
+	}
+}
+
+func (m *MetricsQueue) MarkDeadLetters(v int) {
+	if v != 0 {
+		m.deadLetters.Mark(int64(v))
+	}
+}
+
+func (m *MetricsQueue) Measure(elapsed time.Duration) {
+	m.latency.Update(elapsed)
+}
+
+func (m *MetricsQueue) Get() map[string]float64 {
+	return map[string]float64{
+		"depth_min":          float64(m.depth.Min()),
+		"depth_max":          float64(m.depth.Max()),
+		"depth_avg":          m.depth.Mean(),
+		"processed_count":    float64(m.processed.Count()),
+		"processed_rate":     m.processed.Rate1(),
+		"failures_count":     float64(m.failures.Count()),
+		"failures_rate":      m.failures.Rate1(),
+		"retries_count":      float64(m.retries.Count()),
+		"retries_rate":       m.retries.Rate1(),
+		"dead_letters_count": float64(m.deadLetters.Count()),
+		"dead_letters_rate":  m.deadLetters.Rate1(),
+		"times_min":          float64(m.latency.Min()) / float64(time.Millisecond),
+		"times_max":          float64(m.latency.Max()) / float64(time.Millisecond),
+		"times_avg":          m.latency.Mean() / float64(time.Millisecond),
+		"times_50":           m.latency.Percentile(0.5) / float64(time.Millisecond),
+		"times_75":           m.latency.Percentile(0.75) / float64(time.Millisecond),
+		"times_95":           m.latency.Percentile(0.95) / float64(time.Millisecond),
+		"times_99":           m.latency.Percentile(0.99) / float64(time.Millisecond),
+	}
+}
@@ -0,0 +1,195 @@
+package nlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what an AsyncWriter does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Write wait for room in the buffer.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest discards the record being written.
+	OverflowDropNewest
+
+	// OverflowDropOldest discards the oldest buffered record.
+	OverflowDropOldest
+)
+
+type AsyncConfig struct {
+	// Out is the writer records are flushed to.
+	Out io.Writer
+
+	// Size is the number of records the buffer holds. Defaults to 1024.
+	Size int
+
+	// Policy decides what happens when the buffer is full.
+	Policy OverflowPolicy
+
+	// DropMessage formats the record written after records were dropped.
+	// Defaults to "[WARN] nlog: dropped N log records".
+	DropMessage func(dropped int64) []byte
+}
+
+// AsyncWriter is an io.Writer that queues writes into a bounded buffer and
+// writes them to another writer from a single goroutine, so that logging
+// does not wait on slow output. Call Close, or at least Flush, before the
+// program exits; loggers created by NewLogger flush it before os.Exit.
+type AsyncWriter struct {
+	config AsyncConfig
+	ch     chan []byte
+	done   chan struct{}
+
+	closed bool
+	mu     sync.RWMutex
+
+	queued   atomic.Int64
+	written  atomic.Int64
+	dropped  atomic.Int64
+	total    atomic.Int64
+	flushMu  sync.Mutex
+	flushed  *sync.Cond
+	flushErr error
+}
+
+var _ io.WriteCloser = (*AsyncWriter)(nil)
+
+// NewAsyncWriter constructs an AsyncWriter and starts its flusher.
+func NewAsyncWriter(config *AsyncConfig) *AsyncWriter {
+	c := *config
+	if c.Out == nil {
+		c.Out = os.Stdout
+	}
+	if c.Size <= 0 {
+		c.Size = 1024
+	}
+	if c.DropMessage == nil {
+		c.DropMessage = func(n int64) []byte {
+			return []byte(fmt.Sprintf("[WARN] nlog: dropped %d log records\n", n))
+		}
+	}
+	w := &AsyncWriter{
+		config: c,
+		ch:     make(chan []byte, c.Size),
+		done:   make(chan struct{}),
+	}
+	w.flushed = sync.NewCond(&w.flushMu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. It never reports errors from the underlying
+// writer; those are returned by Flush and Close.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	switch w.config.Policy {
+	case OverflowDropNewest:
+		select {
+		case w.ch <- b:
+		default:
+			w.drop(1)
+			return len(p), nil
+		}
+	case OverflowDropOldest:
+		for sent := false; !sent; {
+			select {
+			case w.ch <- b:
+				sent = true
+			default:
+				select {
+				case <-w.ch:
+					w.drop(1)
+					w.finish()
+				default:
+				}
+			}
+		}
+	default:
+		w.ch <- b
+	}
+	w.queued.Add(1)
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped since the writer was created.
+func (w *AsyncWriter) Dropped() int64 {
+	return w.total.Load()
+}
+
+// Flush waits until every record queued before the call has been written
+// and returns the first error from the underlying writer, if any.
+func (w *AsyncWriter) Flush() error {
+	target := w.queued.Load()
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	for w.written.Load() < target {
+		w.flushed.Wait()
+	}
+	return w.flushErr
+}
+
+// Close flushes the buffer and stops the flusher. Writes after Close fail.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return os.ErrClosed
+	}
+	w.closed = true
+	close(w.ch)
+	w.mu.Unlock()
+	<-w.done
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	return w.flushErr
+}
+
+func (w *AsyncWriter) drop(n int64) {
+	w.dropped.Add(n)
+	w.total.Add(n)
+}
+
+// finish counts a queued record as handled and wakes up Flush.
+func (w *AsyncWriter) finish() {
+	w.flushMu.Lock()
+	w.written.Add(1)
+	w.flushed.Broadcast()
+	w.flushMu.Unlock()
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for b := range w.ch {
+		w.writeDropped()
+		w.write(b)
+		w.finish()
+	}
+	w.writeDropped()
+}
+
+func (w *AsyncWriter) writeDropped() {
+	if n := w.dropped.Swap(0); n > 0 {
+		w.write(w.config.DropMessage(n))
+	}
+}
+
+func (w *AsyncWriter) write(b []byte) {
+	if _, err := w.config.Out.Write(b); err != nil {
+		w.flushMu.Lock()
+		if w.flushErr == nil {
+			w.flushErr = err
+		}
+		w.flushMu.Unlock()
+	}
+}
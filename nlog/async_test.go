@@ -0,0 +1,112 @@
+package nlog
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gateWriter blocks writes until open is closed.
+type gateWriter struct {
+	open chan struct{}
+	buf  bytes.Buffer
+	mu   sync.Mutex
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.open
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&AsyncConfig{Out: &buf, Size: 2})
+	l := NewLogger(&Config{Out: w, Level: Info})
+
+	for i := 0; i < 10; i++ {
+		l.Infof("line %d", i)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 10 {
+		t.Errorf("%d lines written", n)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
+
+func TestAsyncWriterDropNewest(t *testing.T) {
+	out := &gateWriter{open: make(chan struct{})}
+	w := NewAsyncWriter(&AsyncConfig{Out: out, Size: 2, Policy: OverflowDropNewest})
+
+	// the flusher takes "a" and blocks; "b" and "c" fill the buffer
+	for _, s := range []string{"a\n", "b\n", "c\n", "d\n", "e\n"} {
+		w.Write([]byte(s))
+	}
+	close(out.open)
+	w.Close()
+
+	r := out.String()
+	if strings.Contains(r, "e\n") {
+		t.Errorf("%q contains the newest record", r)
+	}
+	if w.Dropped() == 0 || !strings.Contains(r, "nlog: dropped") {
+		t.Errorf("%q does not report dropped records", r)
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	out := &gateWriter{open: make(chan struct{})}
+	w := NewAsyncWriter(&AsyncConfig{Out: out, Size: 2, Policy: OverflowDropOldest})
+
+	for _, s := range []string{"a\n", "b\n", "c\n", "d\n", "e\n"} {
+		w.Write([]byte(s))
+	}
+	close(out.open)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := out.String()
+	if !strings.HasSuffix(r, "d\ne\n") {
+		t.Errorf("%q does not end with the newest records", r)
+	}
+	if w.Dropped() == 0 || !strings.Contains(r, "nlog: dropped") {
+		t.Errorf("%q does not report dropped records", r)
+	}
+}
+
+func TestAsyncWriterFatal(t *testing.T) {
+	if os.Getenv("NLOG_TEST_FATAL") == "1" {
+		w := NewAsyncWriter(&AsyncConfig{Out: os.Stdout})
+		l := NewLogger(&Config{Out: w, Level: Info})
+		l.Fatalf("bye")
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestAsyncWriterFatal$")
+	cmd.Env = append(os.Environ(), "NLOG_TEST_FATAL=1")
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("process exited with %v", err)
+	}
+	if string(out) != "[FATAL] bye\n" {
+		t.Errorf("%q != %q", out, "[FATAL] bye\n")
+	}
+}
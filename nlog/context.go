@@ -0,0 +1,31 @@
+package nlog
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+var defaultLogger = defaultCore.Logger()
+
+// NewContext returns a copy of ctx carrying l, so that code handling a
+// request logs with the fields of the request such as its ID.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or a logger writing
+// through DefaultCore if there is none.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := LookupContext(ctx); ok {
+		return l
+	}
+	return defaultLogger
+}
+
+// LookupContext returns the logger carried by ctx. The second result is
+// false if there is none.
+func LookupContext(ctx context.Context) (Logger, bool) {
+	l, ok := ctx.Value(contextKey{}).(Logger)
+	return l, ok
+}
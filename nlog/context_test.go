@@ -0,0 +1,22 @@
+package nlog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	if l := FromContext(context.Background()); l != defaultLogger {
+		t.Errorf("%v != %v", l, defaultLogger)
+	}
+
+	var buf bytes.Buffer
+	l := NewLogger(&Config{Out: &buf, Level: Info}).With("request_id", "abc")
+	ctx := NewContext(context.Background(), l)
+	FromContext(ctx).Info("handled", "user", "bob")
+	e := "[INFO] handled request_id=abc user=bob\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
@@ -0,0 +1,253 @@
+package nlog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLevel is the level of a Core created without a Config.
+const DefaultLevel = Info
+
+// Core is the output, level and format configuration behind loggers.
+// Loggers sharing a Core, including logv loggers, see changes made to it
+// through any of them.
+type Core struct {
+	name       string
+	stackLevel int
+	sampler    *sampler
+	level      atomic.Int64
+
+	mu      sync.Mutex
+	flag    int
+	encoder Encoder
+	sink    Sink
+	logger  *log.Logger
+	buf     bytes.Buffer
+}
+
+var defaultCore = NewCore(&Config{Level: DefaultLevel, Flag: LstdFlags})
+
+// DefaultCore returns the Core shared by the package level logv functions.
+func DefaultCore() *Core {
+	return defaultCore
+}
+
+func NewCore(config *Config) *Core {
+	var out io.Writer = nil
+	var level int = DefaultLevel
+	var prefix string = ""
+	var flag int = 0
+	var encoder Encoder = nil
+	var sink Sink = nil
+	var name string = ""
+	var sampling *SamplingConfig = nil
+	var stackLevel int = No
+	if config != nil {
+		out = config.Out
+		level = config.Level
+		prefix = config.Prefix
+		flag = config.Flag
+		encoder = config.Encoder
+		sink = config.Sink
+		name = config.Name
+		sampling = config.Sampling
+		stackLevel = config.StackLevel
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	c := &Core{
+		name:       name,
+		stackLevel: stackLevel,
+		flag:       flag,
+		encoder:    encoder,
+		sink:       sink,
+		// the file is added by formatText from the record's call site,
+		// as log.Logger would report a frame inside this package
+		logger: log.New(out, prefix, flag&^(Lshortfile|Llongfile)),
+	}
+	c.level.Store(int64(level))
+	if sampling != nil {
+		c.sampler = newSampler(sampling, c.write)
+	}
+	return c
+}
+
+// Logger returns a Logger writing through the core.
+func (c *Core) Logger() Logger {
+	return &myLogger{core: c}
+}
+
+func (c *Core) SetOutput(out io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger.SetOutput(out)
+}
+
+func (c *Core) SetLevel(level int) {
+	c.level.Store(int64(level))
+}
+
+func (c *Core) Level() int {
+	return int(c.level.Load())
+}
+
+func (c *Core) SetPrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger.SetPrefix(prefix)
+}
+
+func (c *Core) SetFlags(flag int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flag = flag
+	c.logger.SetFlags(flag &^ (Lshortfile | Llongfile))
+}
+
+// SetEncoder sets the encoder of records. If nil, records are written as
+// "[LEVEL] message key=value ..." using the prefix and flags.
+func (c *Core) SetEncoder(encoder Encoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.encoder = encoder
+}
+
+// SetSink sets the sink of records. If nil, records are written to the
+// output.
+func (c *Core) SetSink(sink Sink) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sink = sink
+}
+
+// Enabled reports whether level is enabled for the call site, applying any
+// overrides set by SetVModule. Calldepth is the number of frames to skip as
+// in log.Output; 1 is the caller of Enabled.
+func (c *Core) Enabled(level int, calldepth int) bool {
+	return c.enabled(level, calldepth+1)
+}
+
+// enabled reports whether level is enabled for the call site depth frames
+// above it.
+func (c *Core) enabled(level int, depth int) bool {
+	if level == No {
+		return false
+	}
+	if spec := vmodule.Load(); spec != nil {
+		if override, ok := spec.level(c.name, depth+1); ok {
+			return level <= override
+		}
+	}
+	return level <= c.Level()
+}
+
+// enabledPC is like enabled for the call site at pc, such as that of an
+// slog.Record.
+func (c *Core) enabledPC(level int, pc uintptr) bool {
+	if level == No {
+		return false
+	}
+	if spec := vmodule.Load(); spec != nil {
+		if override, ok := spec.levelPC(c.name, pc); ok {
+			return level <= override
+		}
+	}
+	return level <= c.Level()
+}
+
+// enabledAnywhere reports whether level may be enabled for some call site,
+// for callers that check the level before the call site is known.
+func (c *Core) enabledAnywhere(level int) bool {
+	if level == No {
+		return false
+	}
+	if spec := vmodule.Load(); spec != nil {
+		if override, ok := spec.nameLevel(c.name); ok {
+			return level <= override
+		}
+		if level <= spec.maxLevel() {
+			return true
+		}
+	}
+	return level <= c.Level()
+}
+
+// Output writes msg at level regardless of the core's level. A level of No
+// writes msg without a level. Calldepth is the number of frames to skip
+// when computing the call site as in log.Output; 1 is the caller of Output.
+func (c *Core) Output(calldepth int, level int, msg string) {
+	c.write(c.record(level, msg, nil, calldepth+2))
+}
+
+// record returns a record for the call site skip frames above it, counting
+// runtime.Callers as 0.
+func (c *Core) record(level int, msg string, fields []Field, skip int) *Record {
+	r := &Record{Level: level, Message: msg, Fields: fields}
+	c.mu.Lock()
+	encoded := c.encoder != nil || c.sink != nil
+	file := c.flag&(Lshortfile|Llongfile) != 0
+	c.mu.Unlock()
+	if encoded {
+		r.Time = time.Now()
+	}
+	if encoded || file {
+		var pcs [1]uintptr
+		if runtime.Callers(skip, pcs[:]) > 0 {
+			r.PC = pcs[0]
+		}
+	}
+	if c.stackLevel != No && level != No && level <= c.stackLevel {
+		r.Stack = callerStack(skip)
+	}
+	return r
+}
+
+// write formats a record and writes it to the output.
+func (c *Core) write(r *Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sink != nil {
+		c.sink.WriteRecord(r)
+		return
+	}
+	if c.encoder == nil {
+		c.logger.Print(formatText(r, c.flag))
+		return
+	}
+	c.buf.Reset()
+	c.encoder.Encode(&c.buf, r)
+	c.logger.Writer().Write(c.buf.Bytes())
+}
+
+// Sink receives records in place of the output, for destinations that need
+// the level and fields of records such as syslog and journald.
+type Sink interface {
+	WriteRecord(r *Record) error
+}
+
+// flusher is implemented by outputs and sinks that buffer writes, such as
+// AsyncWriter and Tee.
+type flusher interface {
+	Flush() error
+}
+
+// exit flushes the output or the sink and exits after a fatal record.
+func (c *Core) exit() {
+	c.mu.Lock()
+	var out interface{} = c.logger.Writer()
+	if c.sink != nil {
+		out = c.sink
+	}
+	c.mu.Unlock()
+	if f, ok := out.(flusher); ok {
+		f.Flush()
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,66 @@
+package nlog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestCoreShared(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCore(&Config{Out: &buf, Level: Warn})
+	l1 := c.Logger()
+	l2 := c.Logger().With("k", 1)
+
+	l1.Infof("hidden")
+	c.SetLevel(Info)
+	l1.Infof("one")
+	l2.Info("two")
+	e := "[INFO] one\n[INFO] two k=1\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	var out bytes.Buffer
+	c.SetOutput(&out)
+	c.SetEncoder(LogfmtEncoder{})
+	l2.Warn("three")
+	e = "level=WARN caller=nlog/core_test.go:"
+	if r := out.String(); !bytes.Contains(out.Bytes(), []byte(e)) {
+		t.Errorf("%s does not contain %s", trimLF(r), e)
+	}
+	if r := buf.String(); r != "[INFO] one\n[INFO] two k=1\n" {
+		t.Errorf("%s was written after SetOutput", trimLF(r))
+	}
+}
+
+func TestCoreOutput(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCore(&Config{Out: &buf, Level: Error, Flag: Lshortfile})
+
+	_, _, line, _ := runtime.Caller(0)
+	c.Output(1, No, "plain")
+	c.Output(1, Info, "leveled")
+	e := "core_test.go:" + strconv.Itoa(line+1) + ": plain\n" +
+		"core_test.go:" + strconv.Itoa(line+2) + ": [INFO] leveled\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestCoreEnabled(t *testing.T) {
+	c := NewCore(nil)
+	if c.Level() != DefaultLevel {
+		t.Errorf("%d != %d", c.Level(), DefaultLevel)
+	}
+	if !c.Enabled(Info, 1) {
+		t.Error("Info is disabled")
+	}
+	if c.Enabled(Debug, 1) {
+		t.Error("Debug is enabled")
+	}
+	if c.Enabled(No, 1) {
+		t.Error("No is enabled")
+	}
+}
@@ -0,0 +1,224 @@
+package nlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Field is a key-value pair attached to a log record.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// badKey is the key used for a value that is not preceded by a string key.
+const badKey = "!BADKEY"
+
+// Record is a single log entry as passed to an Encoder.
+type Record struct {
+	Time    time.Time
+	Level   int
+	Message string
+	PC      uintptr // program counter of the call site, or zero
+	Fields  []Field
+	Stack   string // stack trace of the call site, if requested
+}
+
+// Caller returns the "dir/file.go:line" of the record's call site,
+// or an empty string if it is unknown.
+func (r *Record) Caller() string {
+	if r.PC == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	dir, file := filepath.Split(frame.File)
+	return filepath.Base(dir) + "/" + file + ":" + strconv.Itoa(frame.Line)
+}
+
+// Encoder formats a record into buf, including the trailing newline.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, r *Record)
+}
+
+// LogfmtEncoder formats records as logfmt lines:
+//
+//	time=2006-01-02T15:04:05.000Z level=INFO caller=pkg/file.go:12 msg=hello key=value
+type LogfmtEncoder struct{}
+
+func (LogfmtEncoder) Encode(buf *bytes.Buffer, r *Record) {
+	if !r.Time.IsZero() {
+		buf.WriteString("time=")
+		buf.WriteString(r.Time.Format(time.RFC3339Nano))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString("level=")
+	buf.WriteString(LevelToName(r.Level))
+	if caller := r.Caller(); caller != "" {
+		buf.WriteString(" caller=")
+		buf.WriteString(caller)
+	}
+	buf.WriteString(" msg=")
+	appendLogfmtString(buf, r.Message)
+	for _, f := range r.Fields {
+		buf.WriteByte(' ')
+		appendLogfmtField(buf, f)
+	}
+	if r.Stack != "" {
+		buf.WriteString(" stack=")
+		appendLogfmtString(buf, r.Stack)
+	}
+	buf.WriteByte('\n')
+}
+
+// JSONEncoder formats records as one JSON object per line with the keys
+// "time", "level", "caller" and "msg" followed by the record's fields.
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(buf *bytes.Buffer, r *Record) {
+	buf.WriteByte('{')
+	if !r.Time.IsZero() {
+		buf.WriteString(`"time":"`)
+		buf.WriteString(r.Time.Format(time.RFC3339Nano))
+		buf.WriteString(`",`)
+	}
+	buf.WriteString(`"level":"`)
+	buf.WriteString(LevelToName(r.Level))
+	buf.WriteByte('"')
+	if caller := r.Caller(); caller != "" {
+		buf.WriteString(`,"caller":`)
+		appendJSON(buf, caller)
+	}
+	buf.WriteString(`,"msg":`)
+	appendJSON(buf, r.Message)
+	for _, f := range r.Fields {
+		buf.WriteByte(',')
+		appendJSON(buf, f.Key)
+		buf.WriteByte(':')
+		appendJSON(buf, jsonValue(f.Value))
+	}
+	if r.Stack != "" {
+		buf.WriteString(`,"stack":`)
+		appendJSON(buf, r.Stack)
+	}
+	buf.WriteString("}\n")
+}
+
+func appendJSON(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(b)
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch d := v.(type) {
+	case json.Marshaler:
+		return d
+	case error:
+		return errorChain(d)
+	case fmt.Stringer:
+		return d.String()
+	}
+	return v
+}
+
+func appendLogfmtField(buf *bytes.Buffer, f Field) {
+	appendLogfmtString(buf, f.Key)
+	buf.WriteByte('=')
+	appendLogfmtString(buf, logfmtValue(f.Value))
+}
+
+func logfmtValue(v interface{}) string {
+	switch d := v.(type) {
+	case string:
+		return d
+	case error:
+		return errorChain(d)
+	case time.Time:
+		return d.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return d.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func appendLogfmtString(buf *bytes.Buffer, s string) {
+	if needsQuote(s) {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields converts alternating keys and values into fields as the structured
+// methods of Logger do.
+func Fields(kv ...interface{}) []Field {
+	return toFields(kv)
+}
+
+// toFields converts alternating keys and values into fields.
+func toFields(kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for len(kv) > 0 {
+		switch k := kv[0].(type) {
+		case string:
+			if len(kv) == 1 {
+				fields = append(fields, Field{badKey, k})
+				kv = kv[1:]
+			} else {
+				fields = append(fields, Field{k, kv[1]})
+				kv = kv[2:]
+			}
+		case Field:
+			fields = append(fields, k)
+			kv = kv[1:]
+		case slog.Attr:
+			fields = appendAttr(fields, "", k)
+			kv = kv[1:]
+		default:
+			fields = append(fields, Field{badKey, k})
+			kv = kv[1:]
+		}
+	}
+	return fields
+}
+
+// appendFields returns a new slice holding a followed by b, so that loggers
+// sharing a never see each other's fields.
+func appendFields(a, b []Field) []Field {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	ret := make([]Field, 0, len(a)+len(b))
+	ret = append(ret, a...)
+	return append(ret, b...)
+}
@@ -0,0 +1,110 @@
+package nlog
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// errorChain formats an error and the errors it wraps on one line. Causes
+// that the message already ends with are not repeated, causes it leaves out
+// are appended after ": ", and joined errors are listed as "[a; b]".
+func errorChain(err error) string {
+	msg := err.Error()
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		inner := u.Unwrap()
+		if inner == nil {
+			return oneLine(msg)
+		}
+		innerMsg := inner.Error()
+		if msg == innerMsg {
+			return errorChain(inner)
+		}
+		if strings.HasSuffix(msg, innerMsg) {
+			msg = strings.TrimSuffix(msg, innerMsg)
+			msg = strings.TrimSuffix(msg, ": ")
+		} else if strings.Contains(msg, innerMsg) {
+			return oneLine(msg)
+		}
+		return oneLine(msg) + ": " + errorChain(inner)
+	case interface{ Unwrap() []error }:
+		var parts, msgs []string
+		for _, e := range u.Unwrap() {
+			if e != nil {
+				parts = append(parts, errorChain(e))
+				msgs = append(msgs, e.Error())
+			}
+		}
+		list := "[" + strings.Join(parts, "; ") + "]"
+		if msg == strings.Join(msgs, "\n") {
+			return list
+		}
+		return oneLine(msg) + ": " + list
+	}
+	return oneLine(msg)
+}
+
+func oneLine(s string) string {
+	return strings.ReplaceAll(s, "\n", "; ")
+}
+
+// chainError formats a wrapping error with errorChain for the %v and %s
+// verbs and as the error itself for anything else, such as %+v.
+type chainError struct {
+	err error
+}
+
+func (e chainError) Format(f fmt.State, verb rune) {
+	if (verb == 'v' || verb == 's') && !f.Flag('+') && !f.Flag('#') {
+		fmt.Fprintf(f, fmt.FormatString(f, verb), errorChain(e.err))
+		return
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), e.err)
+}
+
+// expandErrors replaces wrapping errors in printf arguments with values
+// that print their whole chain.
+func expandErrors(v []interface{}) []interface{} {
+	var ret []interface{}
+	for i, a := range v {
+		err, ok := a.(error)
+		if !ok {
+			continue
+		}
+		switch err.(type) {
+		case interface{ Unwrap() error }, interface{ Unwrap() []error }:
+			if ret == nil {
+				ret = append([]interface{}(nil), v...)
+			}
+			ret[i] = chainError{err}
+		}
+	}
+	if ret == nil {
+		return v
+	}
+	return ret
+}
+
+// callerStack formats the stack starting skip frames above runtime.Callers
+// like a goroutine trace in a panic.
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package nlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// opError is a wrapping error whose message leaves out its cause.
+type opError struct {
+	op  string
+	err error
+}
+
+func (e *opError) Error() string { return e.op + " failed" }
+func (e *opError) Unwrap() error { return e.err }
+
+func TestErrorChain(t *testing.T) {
+	root := errors.New("disk full")
+	tests := []struct {
+		err error
+		e   string
+	}{
+		{root, "disk full"},
+		{fmt.Errorf("write: %w", root), "write: disk full"},
+		{&opError{"save", fmt.Errorf("write: %w", root)}, "save failed: write: disk full"},
+		{errors.Join(root, &opError{"sync", nil}), "[disk full; sync failed]"},
+		{fmt.Errorf("close: %w", errors.Join(root, errors.New("timeout"))), "close: [disk full; timeout]"},
+	}
+	for _, tt := range tests {
+		if r := errorChain(tt.err); r != tt.e {
+			t.Errorf("%s != %s", r, tt.e)
+		}
+	}
+}
+
+func TestErrorfChain(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&Config{Out: &buf, Level: Info})
+
+	err := &opError{"save", errors.Join(errors.New("a"), errors.New("b"))}
+	l.Errorf("request: %v", err)
+	l.Error("request", "err", err)
+	e := "[ERROR] request: save failed: [a; b]\n[ERROR] request err=\"save failed: [a; b]\"\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestCallerFlag(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&Config{Out: &buf, Level: Info, Flag: Lshortfile})
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Infof("here")
+	l.With("k", 1).Info("there")
+	e := "errors_test.go:" + strconv.Itoa(line+1) + ": [INFO] here\n" +
+		"errors_test.go:" + strconv.Itoa(line+2) + ": [INFO] there k=1\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestStackLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&Config{Out: &buf, Level: Info, StackLevel: Error})
+
+	l.Warnf("no stack")
+	if r := buf.String(); r != "[WARN] no stack\n" {
+		t.Errorf("%s != [WARN] no stack", trimLF(r))
+	}
+
+	buf.Reset()
+	l.Errorf("stack")
+	r := buf.String()
+	lines := strings.Split(r, "\n")
+	if lines[0] != "[ERROR] stack" {
+		t.Errorf("%s != [ERROR] stack", lines[0])
+	}
+	if len(lines) < 3 || !strings.HasSuffix(lines[1], ".TestStackLevel") || !strings.Contains(lines[2], "errors_test.go:") {
+		t.Errorf("stack does not start at the caller:\n%s", r)
+	}
+}
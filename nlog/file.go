@@ -0,0 +1,370 @@
+package nlog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the timestamp in the names of rotated files,
+// as in app-2006-01-02T15-04-05.000.log. Files rotated within the same
+// millisecond get a sequence number, as in app-2006-01-02T15-04-05.000-1.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateInterval is a schedule on which a FileWriter rotates its file.
+type RotateInterval int
+
+const (
+	RotateNone RotateInterval = iota
+	RotateHourly
+	RotateDaily
+)
+
+type FileConfig struct {
+	// Filename is the file to write to. Rotated files are kept next to it.
+	Filename string
+
+	// MaxSize rotates the file before it grows beyond this many bytes.
+	// Zero means no limit.
+	MaxSize int64
+
+	// Interval rotates the file at the start of every hour or day in
+	// local time.
+	Interval RotateInterval
+
+	// MaxBackups is the number of rotated files to keep, and MaxAge is how
+	// long to keep them. Zero means no limit.
+	MaxBackups int
+	MaxAge     time.Duration
+
+	// Compress gzips rotated files in the background.
+	Compress bool
+}
+
+// FileWriter is an io.Writer that writes to a file and rotates it by size
+// or on a schedule. It is safe for concurrent use and can be passed as
+// Config.Out or to logv.SetOutput.
+type FileWriter struct {
+	config FileConfig
+	file   *os.File
+	size   int64
+	next   time.Time
+	now    func() time.Time
+	closed bool
+
+	mill     chan struct{}
+	millDone chan struct{}
+	signals  chan os.Signal
+
+	mu sync.Mutex
+}
+
+var _ io.WriteCloser = (*FileWriter)(nil)
+
+// NewFileWriter opens the file for appending, creating it and its directory
+// if needed.
+func NewFileWriter(config *FileConfig) (*FileWriter, error) {
+	w := &FileWriter{
+		config:   *config,
+		now:      time.Now,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	go w.millRun()
+	return w, nil
+}
+
+// Write writes p to the file, rotating it first if p would exceed MaxSize
+// or the scheduled time for rotation has passed.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		// an earlier rotation or reopen failed to open the file
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the file, renames it to a backup and opens a new file.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file by name. Use it after the file was
+// moved away by another program such as logrotate.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.closeFile()
+	return w.open()
+}
+
+// ReopenOnSignal makes the writer call Reopen whenever the process receives
+// one of the signals, SIGHUP if none are given.
+func (w *FileWriter) ReopenOnSignal(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.signals)
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+	w.signals = ch
+	go func() {
+		for range ch {
+			w.Reopen()
+		}
+	}()
+}
+
+// Close closes the file and waits for background compression to finish.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return os.ErrClosed
+	}
+	w.closed = true
+	err := w.closeFile()
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.signals)
+		w.signals = nil
+	}
+	close(w.mill)
+	w.mu.Unlock()
+	<-w.millDone
+	return err
+}
+
+// closeFile closes the file, leaving w.file nil so that a failed open is
+// retried by the next Write.
+func (w *FileWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *FileWriter) open() error {
+	name := w.config.Filename
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	w.next = w.nextRotation(w.now())
+	return nil
+}
+
+func (w *FileWriter) shouldRotate(n int64) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	return !w.next.IsZero() && !w.now().Before(w.next)
+}
+
+func (w *FileWriter) nextRotation(t time.Time) time.Time {
+	switch w.config.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (w *FileWriter) rotate() error {
+	w.closeFile()
+	name := w.config.Filename
+	renameErr := os.Rename(name, w.backupName(w.now()))
+	if os.IsNotExist(renameErr) {
+		renameErr = nil
+	}
+	// reopen the file even if it could not be renamed, to keep writing
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns a name for a rotated file that no backup, compressed
+// or not, has yet.
+func (w *FileWriter) backupName(t time.Time) string {
+	dir, base := filepath.Split(w.config.Filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-" + t.Format(backupTimeFormat)
+	name := filepath.Join(dir, prefix+ext)
+	for seq := 1; exists(name) || exists(name+".gz"); seq++ {
+		name = filepath.Join(dir, prefix+"-"+strconv.Itoa(seq)+ext)
+	}
+	return name
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// backups returns the rotated files, newest first.
+func (w *FileWriter) backups() ([]backupFile, error) {
+	dir, base := filepath.Split(w.config.Filename)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		if s := strings.TrimSuffix(ts, ext+".gz"); s != ts {
+			ts = s
+		} else if s := strings.TrimSuffix(ts, ext); s != ts || ext == "" {
+			ts = s
+		} else {
+			continue
+		}
+		seq := 0
+		if i := strings.LastIndexByte(ts, '-'); i == len(backupTimeFormat) {
+			if seq, err = strconv.Atoi(ts[i+1:]); err != nil || seq < 1 {
+				continue
+			}
+			ts = ts[:i]
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{filepath.Join(dir, name), t, seq})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].time.Equal(files[j].time) {
+			return files[i].time.After(files[j].time)
+		}
+		return files[i].seq > files[j].seq
+	})
+	return files, nil
+}
+
+// millRun compresses and removes rotated files after each rotation.
+func (w *FileWriter) millRun() {
+	defer close(w.millDone)
+	for range w.mill {
+		w.millOnce()
+	}
+}
+
+func (w *FileWriter) millOnce() {
+	files, err := w.backups()
+	if err != nil {
+		return
+	}
+	var cutoff time.Time
+	if w.config.MaxAge > 0 {
+		cutoff = w.now().Add(-w.config.MaxAge)
+	}
+	for i, f := range files {
+		if (w.config.MaxBackups > 0 && i >= w.config.MaxBackups) ||
+			(!cutoff.IsZero() && f.time.Before(cutoff)) {
+			os.Remove(f.path)
+			continue
+		}
+		if w.config.Compress && !strings.HasSuffix(f.path, ".gz") {
+			compressFile(f.path)
+		}
+	}
+}
+
+// compressFile gzips a file and removes the original.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
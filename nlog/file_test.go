@@ -0,0 +1,205 @@
+package nlog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, name string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFileWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(&FileConfig{Filename: name, MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, s := range []string{"12345\n", "67890\n", "abcde\n", "fghij\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := readFile(t, name); r != "fghij\n" {
+		t.Errorf("%q != %q", r, "fghij\n")
+	}
+	files, _ := w.backups()
+	if len(files) != 2 {
+		t.Fatalf("%d backups kept", len(files))
+	}
+	if r := readFile(t, files[0].path); r != "abcde\n" {
+		t.Errorf("%q != %q", r, "abcde\n")
+	}
+	if !strings.HasPrefix(filepath.Base(files[0].path), "app-2026-01-02T03-04-") {
+		t.Errorf("unexpected backup name %s", files[0].path)
+	}
+}
+
+func TestFileWriterInterval(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 23, 59, 0, 0, time.Local)
+	w, err := NewFileWriter(&FileConfig{Filename: name, Interval: RotateDaily, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return now }
+	w.next = w.nextRotation(now)
+
+	w.Write([]byte("day1\n"))
+	now = now.Add(2 * time.Minute)
+	w.Write([]byte("day2\n"))
+	w.Close()
+
+	if r := readFile(t, name); r != "day2\n" {
+		t.Errorf("%q != %q", r, "day2\n")
+	}
+	files, _ := w.backups()
+	if len(files) != 1 || !strings.HasSuffix(files[0].path, ".log.gz") {
+		t.Fatalf("unexpected backups %v", files)
+	}
+	f, _ := os.Open(files[0].path)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(gz)
+	if string(b) != "day1\n" {
+		t.Errorf("%q != %q", b, "day1\n")
+	}
+}
+
+func TestFileWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(&FileConfig{Filename: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("before\n"))
+	os.Rename(name, name+".1")
+	w.Write([]byte("moved\n"))
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+
+	if r := readFile(t, name+".1"); r != "before\nmoved\n" {
+		t.Errorf("%q != %q", r, "before\nmoved\n")
+	}
+	if r := readFile(t, name); r != "after\n" {
+		t.Errorf("%q != %q", r, "after\n")
+	}
+}
+
+func TestFileWriterReopenFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	name := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(&FileConfig{Filename: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// a file in place of the directory makes opening the log fail
+	os.Rename(dir, dir+".old")
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reopen(); err == nil {
+		t.Fatal("no error")
+	}
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Fatal("no error")
+	}
+
+	os.Remove(dir)
+	if _, err := w.Write([]byte("recovered\n")); err != nil {
+		t.Fatal(err)
+	}
+	if r := readFile(t, name); r != "recovered\n" {
+		t.Errorf("%q != %q", r, "recovered\n")
+	}
+}
+
+func TestFileWriterSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(&FileConfig{Filename: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local) }
+
+	for _, s := range []string{"1\n", "2\n", "3\n"} {
+		w.Write([]byte(s))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	files, _ := w.backups()
+	if len(files) != 3 {
+		t.Fatalf("%d backups kept", len(files))
+	}
+	for i, want := range []string{"3\n", "2\n", "1\n"} {
+		if r := readFile(t, files[i].path); r != want {
+			t.Errorf("%s: %q != %q", files[i].path, r, want)
+		}
+	}
+	if base := filepath.Base(files[0].path); base != "app-2026-01-02T03-04-05.000-2.log" {
+		t.Errorf("unexpected backup name %s", base)
+	}
+}
+
+func TestFileWriterMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewFileWriter(&FileConfig{Filename: name, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.Local)
+	w.now = func() time.Time { return now }
+
+	old := w.backupName(now.Add(-48 * time.Hour))
+	recent := w.backupName(now.Add(-time.Hour))
+	for _, f := range []string{old, recent} {
+		if err := os.WriteFile(f, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.millOnce()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("%s not removed", old)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("%s removed: %v", recent, err)
+	}
+}
@@ -0,0 +1,132 @@
+package nlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const journalAddr = "/run/systemd/journal/socket"
+
+type JournalConfig struct {
+	// Addr is the path of the journal socket, /run/systemd/journal/socket
+	// if empty.
+	Addr string
+
+	// Identifier is the SYSLOG_IDENTIFIER of records, the program name
+	// if empty.
+	Identifier string
+}
+
+// JournalSink writes records to journald with its native protocol. Fields
+// of records are sent as journal fields with their keys upper-cased and
+// other characters than A-Z, 0-9 and '_' replaced by '_'.
+//
+// Each record is sent as a single datagram, so records larger than the
+// socket buffer fail with an error.
+type JournalSink struct {
+	config JournalConfig
+	conn   net.Conn
+	buf    bytes.Buffer
+	mu     sync.Mutex
+}
+
+var _ Sink = (*JournalSink)(nil)
+
+func NewJournalSink(config *JournalConfig) (*JournalSink, error) {
+	s := &JournalSink{config: *config}
+	if s.config.Addr == "" {
+		s.config.Addr = journalAddr
+	}
+	if s.config.Identifier == "" {
+		s.config.Identifier = programName()
+	}
+	conn, err := net.Dial("unixgram", s.config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("nlog: journal: %w", err)
+	}
+	s.conn = conn
+	return s, nil
+}
+
+func (s *JournalSink) WriteRecord(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return os.ErrClosed
+	}
+	s.buf.Reset()
+	appendJournalField(&s.buf, "MESSAGE", r.Message)
+	appendJournalField(&s.buf, "PRIORITY", strconv.Itoa(SyslogSeverity(r.Level)))
+	appendJournalField(&s.buf, "SYSLOG_IDENTIFIER", s.config.Identifier)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			appendJournalField(&s.buf, "CODE_FILE", frame.File)
+			appendJournalField(&s.buf, "CODE_LINE", strconv.Itoa(frame.Line))
+			appendJournalField(&s.buf, "CODE_FUNC", frame.Function)
+		}
+	}
+	for _, f := range r.Fields {
+		appendJournalField(&s.buf, journalKey(f.Key), logfmtValue(f.Value))
+	}
+	if r.Stack != "" {
+		appendJournalField(&s.buf, "STACK", r.Stack)
+	}
+	_, err := s.conn.Write(s.buf.Bytes())
+	return err
+}
+
+func (s *JournalSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// appendJournalField appends "KEY=value\n", or KEY followed by the little
+// endian length of the value if the value spans lines.
+func appendJournalField(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalKey makes a valid journal field name of key. Names must not start
+// with '_' or a digit, which are reserved or invalid.
+func journalKey(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	name := strings.TrimLeft(string(b), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
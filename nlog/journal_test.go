@@ -0,0 +1,58 @@
+package nlog
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestJournalSink(t *testing.T) {
+	conn, addr := listenUnixgram(t)
+	s, err := NewJournalSink(&JournalConfig{Addr: addr, Identifier: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	l := NewLogger(&Config{Sink: s, Level: Info})
+
+	l.Error("failed", "user-id", 7, "note", "line1\nline2")
+	r := readDatagram(t, conn)
+	for _, e := range []string{
+		"MESSAGE=failed\n",
+		"PRIORITY=3\n",
+		"SYSLOG_IDENTIFIER=app\n",
+		"CODE_FILE=",
+		"CODE_FUNC=github.com/najeira/goutils/nlog.TestJournalSink\n",
+		"USER_ID=7\n",
+	} {
+		if !strings.Contains(r, e) {
+			t.Errorf("%q does not contain %q", r, e)
+		}
+	}
+
+	value := "line1\nline2"
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	e := "NOTE\n" + string(size[:]) + value + "\n"
+	if !strings.Contains(r, e) {
+		t.Errorf("%q does not contain %q", r, e)
+	}
+}
+
+func TestJournalKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		name string
+	}{
+		{"message", "MESSAGE"},
+		{"user.id", "USER_ID"},
+		{"_secret", "SECRET"},
+		{"1st", "F_1ST"},
+		{"", "F_"},
+	}
+	for _, test := range tests {
+		if r := journalKey(test.key); r != test.name {
+			t.Errorf("%s != %s", r, test.name)
+		}
+	}
+}
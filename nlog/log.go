@@ -1,10 +1,13 @@
 package nlog
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
-	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -44,6 +47,19 @@ type Logger interface {
 	Warnf(format string, v ...interface{})
 	Errorf(format string, v ...interface{})
 	Fatalf(format string, v ...interface{})
+
+	// The structured methods take a message followed by alternating keys
+	// and values, as in Info("saved", "id", 123, "took", d). A Field or an
+	// slog.Attr can be passed in place of a key and value.
+	Trace(msg string, kv ...interface{})
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+	Fatal(msg string, kv ...interface{})
+
+	// With returns a child logger that adds the given fields to every record.
+	With(kv ...interface{}) Logger
 }
 
 type Config struct {
@@ -51,33 +67,34 @@ type Config struct {
 	Level  int
 	Prefix string
 	Flag   int
+
+	// Encoder formats records written to Out. If nil, records are written
+	// as "[LEVEL] message key=value ..." using Prefix and Flag.
+	Encoder Encoder
+
+	// Sink receives records in place of Out and Encoder if not nil.
+	Sink Sink
+
+	// Name identifies the logger in SetVModule patterns.
+	Name string
+
+	// Sampling limits repeated records if not nil.
+	Sampling *SamplingConfig
+
+	// StackLevel attaches a stack trace to records at this level or more
+	// severe, such as Error for Errorf and Fatalf. No disables stack traces.
+	StackLevel int
 }
 
 type myLogger struct {
-	level  int
-	logger *log.Logger
+	core   *Core
+	fields []Field
 }
 
 var _ Logger = (*myLogger)(nil)
 
 func NewLogger(config *Config) Logger {
-	var out io.Writer = nil
-	var level int = Info
-	var prefix string = ""
-	var flag int = 0
-	if config != nil {
-		out = config.Out
-		level = config.Level
-		prefix = config.Prefix
-		flag = config.Flag
-	}
-	if out == nil {
-		out = os.Stdout
-	}
-	return &myLogger{
-		level:  level,
-		logger: log.New(out, prefix, flag),
-	}
+	return NewCore(config).Logger()
 }
 
 func LevelToName(level int) string {
@@ -98,40 +115,143 @@ func NameToLevel(name string) int {
 }
 
 func (l *myLogger) V(level int) bool {
-	return level <= l.level && level != No
+	// the caller of V is two frames above enabled
+	return l.core.enabled(level, 2)
 }
 
 func (l *myLogger) Tracef(format string, v ...interface{}) {
-	l.Printf(Trace, format, v...)
+	l.logf(Trace, format, v...)
 }
 
 func (l *myLogger) Debugf(format string, v ...interface{}) {
-	l.Printf(Debug, format, v...)
+	l.logf(Debug, format, v...)
 }
 
 func (l *myLogger) Infof(format string, v ...interface{}) {
-	l.Printf(Info, format, v...)
+	l.logf(Info, format, v...)
 }
 
 func (l *myLogger) Warnf(format string, v ...interface{}) {
-	l.Printf(Warn, format, v...)
+	l.logf(Warn, format, v...)
 }
 
 func (l *myLogger) Errorf(format string, v ...interface{}) {
-	l.Printf(Error, format, v...)
+	l.logf(Error, format, v...)
 }
 
 func (l *myLogger) Fatalf(format string, v ...interface{}) {
-	l.Printf(Fatal, format, v...)
-	os.Exit(1)
+	l.logf(Fatal, format, v...)
+	l.core.exit()
 }
 
 func (l *myLogger) Printf(level int, format string, v ...interface{}) {
-	if level > l.level {
+	l.logf(level, format, v...)
+}
+
+func (l *myLogger) logf(level int, format string, v ...interface{}) {
+	// the caller of the exported method is three frames above enabled
+	if !l.core.enabled(level, 3) {
 		return
 	}
-	if name := LevelToName(level); name != "" {
-		format = fmt.Sprintf("[%s] %s", name, format)
-		l.logger.Printf(format, v...)
+	if l.core.sampler != nil && !l.core.sampler.allow(level, format) {
+		return
+	}
+	l.output(level, fmt.Sprintf(format, expandErrors(v)...), nil)
+}
+
+func (l *myLogger) Trace(msg string, kv ...interface{}) {
+	l.logkv(Trace, msg, kv)
+}
+
+func (l *myLogger) Debug(msg string, kv ...interface{}) {
+	l.logkv(Debug, msg, kv)
+}
+
+func (l *myLogger) Info(msg string, kv ...interface{}) {
+	l.logkv(Info, msg, kv)
+}
+
+func (l *myLogger) Warn(msg string, kv ...interface{}) {
+	l.logkv(Warn, msg, kv)
+}
+
+func (l *myLogger) Error(msg string, kv ...interface{}) {
+	l.logkv(Error, msg, kv)
+}
+
+func (l *myLogger) Fatal(msg string, kv ...interface{}) {
+	l.logkv(Fatal, msg, kv)
+	l.core.exit()
+}
+
+// Print writes a structured record at the given level.
+func (l *myLogger) Print(level int, msg string, kv ...interface{}) {
+	l.logkv(level, msg, kv)
+}
+
+func (l *myLogger) logkv(level int, msg string, kv []interface{}) {
+	if !l.core.enabled(level, 3) {
+		return
+	}
+	if l.core.sampler != nil && !l.core.sampler.allow(level, msg) {
+		return
+	}
+	l.output(level, msg, toFields(kv))
+}
+
+func (l *myLogger) With(kv ...interface{}) Logger {
+	child := *l
+	child.fields = appendFields(l.fields, toFields(kv))
+	return &child
+}
+
+// output writes a record for the caller of the exported logging method.
+func (l *myLogger) output(level int, msg string, fields []Field) {
+	// skip runtime.Callers, record, output, logf/logkv and the exported method
+	l.log(l.core.record(level, msg, fields, 5))
+}
+
+// log adds the logger's fields to a record and writes it.
+func (l *myLogger) log(r *Record) {
+	if LevelToName(r.Level) == "" {
+		return
+	}
+	r.Fields = appendFields(l.fields, r.Fields)
+	l.core.write(r)
+}
+
+// formatText formats a record as "[LEVEL] message key=value ...", preceded
+// by the call site if flag has Lshortfile or Llongfile and followed by the
+// stack trace if any. The level is left out for a record at No.
+func formatText(r *Record, flag int) string {
+	var b bytes.Buffer
+	if flag&(Lshortfile|Llongfile) != 0 {
+		file, line := "???", 0
+		if r.PC != 0 {
+			frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+			file, line = frame.File, frame.Line
+		}
+		if flag&Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		b.WriteString(file)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(line))
+		b.WriteString(": ")
+	}
+	if name := LevelToName(r.Level); name != "" {
+		b.WriteString("[")
+		b.WriteString(name)
+		b.WriteString("] ")
+	}
+	b.WriteString(r.Message)
+	for _, f := range r.Fields {
+		b.WriteByte(' ')
+		appendLogfmtField(&b, f)
+	}
+	if r.Stack != "" {
+		b.WriteByte('\n')
+		b.WriteString(r.Stack)
 	}
+	return b.String()
 }
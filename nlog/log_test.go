@@ -2,8 +2,13 @@ package nlog
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func trimLF(s string) string {
@@ -16,6 +21,24 @@ func trimLF(s string) string {
 	return s
 }
 
+// syncBuffer is a bytes.Buffer that can be written from other goroutines.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
 func TestPrintf(t *testing.T) {
 	var buf bytes.Buffer
 	config := Config{Out: &buf, Level: Warn}
@@ -25,7 +48,7 @@ func TestPrintf(t *testing.T) {
 
 	buf.Reset()
 	w = "Test for Info"
-	l.Infof(w)
+	l.Infof("%s", w)
 	e = ""
 	r = buf.String()
 	if r != e {
@@ -34,7 +57,7 @@ func TestPrintf(t *testing.T) {
 
 	buf.Reset()
 	w = "Test for Warn"
-	l.Warnf(w)
+	l.Warnf("%s", w)
 	e = fmt.Sprintf("[WARN] %s\n", w)
 	r = buf.String()
 	if r != e {
@@ -43,10 +66,87 @@ func TestPrintf(t *testing.T) {
 
 	buf.Reset()
 	w = "Test for Error"
-	l.Errorf(w)
+	l.Errorf("%s", w)
 	e = fmt.Sprintf("[ERROR] %s\n", w)
 	r = buf.String()
 	if r != e {
 		t.Errorf("%s != %s", trimLF(r), trimLF(e))
 	}
 }
+
+func TestStructured(t *testing.T) {
+	var buf bytes.Buffer
+	config := Config{Out: &buf, Level: Info}
+	l := NewLogger(&config)
+
+	l.Info("saved", "id", 123, "name", "Alice Smith")
+	e := "[INFO] saved id=123 name=\"Alice Smith\"\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	buf.Reset()
+	l.Debug("hidden", "id", 123)
+	if r := buf.String(); r != "" {
+		t.Errorf("%s != ", trimLF(r))
+	}
+
+	buf.Reset()
+	l.With("request", "abc").With("user", 1).Warn("slow", "took", "2s", 42)
+	e = "[WARN] slow request=abc user=1 took=2s !BADKEY=42\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	config := Config{Out: &buf, Level: Info, Encoder: LogfmtEncoder{}}
+	l := NewLogger(&config).With("request", "abc")
+
+	l.Info("hello world", "err", errors.New("x=1"))
+	r := buf.String()
+	if !strings.HasPrefix(r, "time=") {
+		t.Errorf("%s has no time", trimLF(r))
+	}
+	e := " level=INFO caller=nlog/log_test.go:"
+	if !strings.Contains(r, e) {
+		t.Errorf("%s does not contain %s", trimLF(r), e)
+	}
+	e = " msg=\"hello world\" request=abc err=\"x=1\"\n"
+	if !strings.HasSuffix(r, e) {
+		t.Errorf("%s does not end with %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	config := Config{Out: &buf, Level: Info, Encoder: JSONEncoder{}}
+	l := NewLogger(&config)
+
+	l.Errorf("failed %d times", 3)
+	l.Info("saved", "id", 123, "err", errors.New("oops"))
+
+	d := json.NewDecoder(&buf)
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m["level"] != "ERROR" || m["msg"] != "failed 3 times" {
+		t.Errorf("unexpected record %v", m)
+	}
+	if c, _ := m["caller"].(string); !strings.HasPrefix(c, "nlog/log_test.go:") {
+		t.Errorf("unexpected caller %v", m["caller"])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, m["time"].(string)); err != nil {
+		t.Error(err)
+	}
+
+	m = nil
+	if err := d.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m["msg"] != "saved" || m["id"] != float64(123) || m["err"] != "oops" {
+		t.Errorf("unexpected record %v", m)
+	}
+}
@@ -0,0 +1,221 @@
+// Package nlogtest provides an nlog.Logger that records entries for
+// assertions in tests.
+package nlogtest
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+// Entry is a record written to a Logger.
+type Entry struct {
+	Level   int
+	Message string
+	Fields  []nlog.Field
+	Caller  string // "dir/file.go:line" of the call site
+}
+
+// Field returns the value of the last field with the key.
+func (e Entry) Field(key string) (interface{}, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == key {
+			return e.Fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+func (e Entry) String() string {
+	var b bytes.Buffer
+	b.WriteString("[")
+	b.WriteString(nlog.LevelToName(e.Level))
+	b.WriteString("] ")
+	b.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
+
+// Logger is an nlog.Logger that records entries in memory and writes them
+// to the test log. Fatalf and Fatal fail the test with t.Fatal instead of
+// exiting, so like t.Fatal they must be called from the test goroutine.
+type Logger struct {
+	t      testing.TB
+	level  int
+	fields []nlog.Field
+	rec    *recorder
+}
+
+// recorder holds the entries shared by a Logger and its children.
+type recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+var _ nlog.Logger = (*Logger)(nil)
+
+// New returns a Logger for t with all levels enabled.
+func New(t testing.TB) *Logger {
+	return &Logger{t: t, level: nlog.Trace, rec: &recorder{}}
+}
+
+// SetLevel sets the most verbose level that is enabled.
+func (l *Logger) SetLevel(level int) {
+	l.level = level
+}
+
+// Entries returns the entries written to the logger and its children.
+func (l *Logger) Entries() []Entry {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	return append([]Entry(nil), l.rec.entries...)
+}
+
+// Reset removes the entries.
+func (l *Logger) Reset() {
+	l.rec.mu.Lock()
+	defer l.rec.mu.Unlock()
+	l.rec.entries = nil
+}
+
+// Logged reports whether an entry at level has a message containing substr.
+func (l *Logger) Logged(level int, substr string) bool {
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails t unless an entry at level has a message containing
+// substr.
+func (l *Logger) AssertLogged(t testing.TB, level int, substr string) {
+	t.Helper()
+	if !l.Logged(level, substr) {
+		t.Errorf("no %s entry contains %q in:\n%s", nlog.LevelToName(level), substr, l.dump())
+	}
+}
+
+// AssertNotLogged fails t if an entry at level has a message containing
+// substr.
+func (l *Logger) AssertNotLogged(t testing.TB, level int, substr string) {
+	t.Helper()
+	if l.Logged(level, substr) {
+		t.Errorf("a %s entry contains %q in:\n%s", nlog.LevelToName(level), substr, l.dump())
+	}
+}
+
+func (l *Logger) dump() string {
+	var b strings.Builder
+	for _, e := range l.Entries() {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (l *Logger) V(level int) bool {
+	return level <= l.level && level > nlog.No
+}
+
+func (l *Logger) Tracef(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Trace, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Debug, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Info, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Warn, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Error, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Fatal, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) Trace(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Trace, msg, kv)
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Debug, msg, kv)
+}
+
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Info, msg, kv)
+}
+
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Warn, msg, kv)
+}
+
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Error, msg, kv)
+}
+
+func (l *Logger) Fatal(msg string, kv ...interface{}) {
+	l.t.Helper()
+	l.log(nlog.Fatal, msg, kv)
+}
+
+func (l *Logger) With(kv ...interface{}) nlog.Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], nlog.Fields(kv...)...)
+	return &child
+}
+
+// log records an entry for the caller of the exported method and writes it
+// to the test log, failing the test if the entry is fatal.
+func (l *Logger) log(level int, msg string, kv []interface{}) {
+	l.t.Helper()
+	if !l.V(level) {
+		return
+	}
+	fields := l.fields
+	if len(kv) > 0 {
+		fields = append(fields[:len(fields):len(fields)], nlog.Fields(kv...)...)
+	}
+	var pcs [1]uintptr
+	// skip runtime.Callers, log and the exported method
+	runtime.Callers(3, pcs[:])
+	r := nlog.Record{PC: pcs[0]}
+	e := Entry{Level: level, Message: msg, Fields: fields, Caller: r.Caller()}
+
+	l.rec.mu.Lock()
+	l.rec.entries = append(l.rec.entries, e)
+	l.rec.mu.Unlock()
+
+	if level == nlog.Fatal {
+		l.t.Fatal(e.String())
+	} else {
+		l.t.Log(e.String())
+	}
+}
@@ -0,0 +1,94 @@
+package nlogtest
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+// fakeT records the failures of a Logger's test.
+type fakeT struct {
+	testing.TB
+	mu     sync.Mutex
+	failed bool
+	logs   []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Log(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, args[0].(string))
+}
+
+func (t *fakeT) Fatal(args ...interface{}) {
+	t.Log(args...)
+	t.mu.Lock()
+	t.failed = true
+	t.mu.Unlock()
+	runtime.Goexit()
+}
+
+func TestLogger(t *testing.T) {
+	l := New(t)
+	var logger nlog.Logger = l
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Infof("saved %d", 1)
+	logger.With("user", "bob").Error("failed", "code", 500)
+	logger.Debug("step")
+
+	l.AssertLogged(t, nlog.Info, "saved 1")
+	l.AssertLogged(t, nlog.Error, "failed")
+	l.AssertNotLogged(t, nlog.Warn, "saved")
+
+	entries := l.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("%d != 3", len(entries))
+	}
+	e := "nlogtest/nlogtest_test.go:" + strconv.Itoa(line+1)
+	if entries[0].Caller != e {
+		t.Errorf("%s != %s", entries[0].Caller, e)
+	}
+	if v, ok := entries[1].Field("user"); !ok || v != "bob" {
+		t.Errorf("%v != bob", v)
+	}
+	if s := entries[1].String(); s != "[ERROR] failed user=bob code=500" {
+		t.Errorf("%s != [ERROR] failed user=bob code=500", s)
+	}
+
+	l.SetLevel(nlog.Info)
+	logger.Debug("hidden")
+	if len(l.Entries()) != 3 {
+		t.Errorf("%d != 3", len(l.Entries()))
+	}
+
+	l.Reset()
+	if len(l.Entries()) != 0 {
+		t.Errorf("%d != 0", len(l.Entries()))
+	}
+}
+
+func TestLoggerFatal(t *testing.T) {
+	ft := &fakeT{TB: t}
+	l := New(ft)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Fatalf("boom %d", 1)
+		l.Info("unreachable")
+	}()
+	<-done
+
+	if !ft.failed {
+		t.Error("not failed")
+	}
+	if len(ft.logs) != 1 || ft.logs[0] != "[FATAL] boom 1" {
+		t.Errorf("%v", ft.logs)
+	}
+	l.AssertLogged(t, nlog.Fatal, "boom")
+}
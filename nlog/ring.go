@@ -0,0 +1,227 @@
+package nlog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type RingConfig struct {
+	// Size is the maximum number of records kept, 1000 if zero.
+	Size int
+
+	// MaxBytes is the maximum total size of the formatted records kept,
+	// 1 MiB if zero. Records larger than MaxBytes are truncated.
+	MaxBytes int
+
+	// Encoder formats records. If nil, LogfmtEncoder is used.
+	Encoder Encoder
+}
+
+// RingSink is a Sink that keeps the most recent records in memory, dropping
+// the oldest ones when it holds more than Size records or MaxBytes bytes.
+//
+// It is also an http.Handler that writes the records as text, or streams
+// them as Server-Sent Events if the request accepts text/event-stream. The
+// query parameters "level" and "q" keep only records at the named level or
+// more severe and records that contain q.
+//
+//	mux.Handle("/debug/log", ring)
+type RingSink struct {
+	size     int
+	maxBytes int
+	encoder  Encoder
+
+	mu      sync.Mutex
+	entries []ringEntry
+	head    int
+	count   int
+	bytes   int
+	seq     uint64
+	buf     bytes.Buffer
+	subs    map[chan ringEntry]struct{}
+}
+
+type ringEntry struct {
+	seq   uint64
+	level int
+	line  string // formatted record without the trailing newline
+}
+
+var (
+	_ Sink         = (*RingSink)(nil)
+	_ http.Handler = (*RingSink)(nil)
+)
+
+func NewRingSink(config *RingConfig) *RingSink {
+	s := &RingSink{
+		size:     1000,
+		maxBytes: 1 << 20,
+		encoder:  LogfmtEncoder{},
+		subs:     make(map[chan ringEntry]struct{}),
+	}
+	if config != nil {
+		if config.Size > 0 {
+			s.size = config.Size
+		}
+		if config.MaxBytes > 0 {
+			s.maxBytes = config.MaxBytes
+		}
+		if config.Encoder != nil {
+			s.encoder = config.Encoder
+		}
+	}
+	s.entries = make([]ringEntry, s.size)
+	return s
+}
+
+func (s *RingSink) WriteRecord(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+	s.encoder.Encode(&s.buf, r)
+	line := strings.TrimSuffix(s.buf.String(), "\n")
+	if len(line) > s.maxBytes {
+		// cut at a rune boundary, and copy so that the entry does not keep
+		// the whole line in memory
+		n := s.maxBytes
+		for n > 0 && !utf8.RuneStart(line[n]) {
+			n--
+		}
+		line = strings.Clone(line[:n])
+	}
+	for s.count > 0 && (s.count == s.size || s.bytes+len(line) > s.maxBytes) {
+		s.bytes -= len(s.entries[s.head].line)
+		s.entries[s.head] = ringEntry{}
+		s.head = (s.head + 1) % s.size
+		s.count--
+	}
+	s.seq++
+	e := ringEntry{seq: s.seq, level: r.Level, line: line}
+	s.entries[(s.head+s.count)%s.size] = e
+	s.count++
+	s.bytes += len(line)
+	for ch := range s.subs {
+		// a slow reader misses records rather than blocking the logger
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	return nil
+}
+
+// Lines returns the formatted records at level or more severe that contain
+// substr, oldest first. Records without a level are always included.
+func (s *RingSink) Lines(level int, substr string) []string {
+	var lines []string
+	for _, e := range s.snapshot(0) {
+		if e.match(level, substr) {
+			lines = append(lines, e.line)
+		}
+	}
+	return lines
+}
+
+// Length returns the number of records kept.
+func (s *RingSink) Length() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// snapshot returns the entries after seq.
+func (s *RingSink) snapshot(seq uint64) []ringEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]ringEntry, 0, s.count)
+	for i := 0; i < s.count; i++ {
+		if e := s.entries[(s.head+i)%s.size]; e.seq > seq {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (e ringEntry) match(level int, substr string) bool {
+	return e.level <= level && strings.Contains(e.line, substr)
+}
+
+func (s *RingSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	level := Trace
+	if name := r.FormValue("level"); name != "" {
+		if level = NameToLevel(name); level == No {
+			http.Error(w, "unknown level: "+name, http.StatusBadRequest)
+			return
+		}
+	}
+	substr := r.FormValue("q")
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.stream(w, r, level, substr)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range s.Lines(level, substr) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// stream writes the records kept and then new records as Server-Sent
+// Events until the request is done. The id of events is the sequence number
+// of records, so a reconnecting client continues after Last-Event-ID.
+func (s *RingSink) stream(w http.ResponseWriter, r *http.Request, level int, substr string) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	last, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	ch := make(chan ringEntry, 64)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, e := range s.snapshot(last) {
+		if e.match(level, substr) {
+			writeEvent(w, e)
+		}
+		last = e.seq
+	}
+	f.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			// skip records already sent from the snapshot
+			if e.seq <= last || !e.match(level, substr) {
+				continue
+			}
+			last = e.seq
+			writeEvent(w, e)
+			f.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e ringEntry) {
+	fmt.Fprintf(w, "id: %d\n", e.seq)
+	for _, line := range strings.Split(e.line, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,127 @@
+package nlog
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingSink(t *testing.T) {
+	s := NewRingSink(&RingConfig{Size: 3, Encoder: JSONEncoder{}})
+	l := NewLogger(&Config{Sink: s, Level: Trace})
+	for i := 0; i < 5; i++ {
+		l.Infof("info %d", i)
+	}
+	l.Error("failed")
+
+	if s.Length() != 3 {
+		t.Errorf("%d != 3", s.Length())
+	}
+	lines := s.Lines(Trace, "")
+	if len(lines) != 3 || !strings.Contains(lines[0], "info 3") {
+		t.Errorf("%v", lines)
+	}
+	if lines := s.Lines(Error, ""); len(lines) != 1 || !strings.Contains(lines[0], "failed") {
+		t.Errorf("%v", lines)
+	}
+	if lines := s.Lines(Trace, "info 4"); len(lines) != 1 {
+		t.Errorf("%v", lines)
+	}
+}
+
+func TestRingSinkMaxBytes(t *testing.T) {
+	s := NewRingSink(&RingConfig{MaxBytes: 20, Encoder: LogfmtEncoder{}})
+	s.WriteRecord(&Record{Level: Info, Message: "a"})  // level=INFO msg=a
+	s.WriteRecord(&Record{Level: Info, Message: "bc"}) // level=INFO msg=bc
+	if lines := s.Lines(Trace, ""); len(lines) != 1 || lines[0] != "level=INFO msg=bc" {
+		t.Errorf("%v", lines)
+	}
+
+	s.WriteRecord(&Record{Level: Info, Message: "too long to be kept"})
+	e := "level=INFO msg=\"too "
+	if lines := s.Lines(Trace, ""); len(lines) != 1 || lines[0] != e {
+		t.Errorf("%v != %s", lines, e)
+	}
+
+	// the cut falls in the middle of the third rune
+	s.WriteRecord(&Record{Level: Info, Message: "ééé"})
+	e = "level=INFO msg=éé"
+	if lines := s.Lines(Trace, ""); len(lines) != 1 || lines[0] != e {
+		t.Errorf("%v != %s", lines, e)
+	}
+}
+
+func TestRingSinkHTTP(t *testing.T) {
+	s := NewRingSink(nil)
+	l := NewLogger(&Config{Sink: s, Level: Trace})
+	l.Info("saved", "id", 1)
+	l.Warn("slow")
+	l.Debug("step")
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/?level=warn", nil))
+	if r := rec.Body.String(); strings.Count(r, "\n") != 1 || !strings.Contains(r, "msg=slow") {
+		t.Errorf("%s", r)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/?q=id%3D1", nil))
+	if r := rec.Body.String(); strings.Count(r, "\n") != 1 || !strings.Contains(r, "msg=saved") {
+		t.Errorf("%s", r)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/?level=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("%d != %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRingSinkStream(t *testing.T) {
+	s := NewRingSink(nil)
+	l := NewLogger(&Config{Sink: s, Level: Trace})
+	l.Info("first")
+	l.Debug("hidden")
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	req, _ := http.NewRequest("GET", srv.URL+"?level=info", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if r := res.Header.Get("Content-Type"); r != "text/event-stream" {
+		t.Errorf("%s != text/event-stream", r)
+	}
+
+	l.Debug("hidden")
+	l.Info("second")
+
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	var data []string
+	for len(data) < 2 {
+		select {
+		case line := <-lines:
+			if strings.HasPrefix(line, "data: ") {
+				data = append(data, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout: %v", data)
+		}
+	}
+	if !strings.Contains(data[0], "msg=first") || !strings.Contains(data[1], "msg=second") {
+		t.Errorf("%v", data)
+	}
+}
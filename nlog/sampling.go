@@ -0,0 +1,118 @@
+package nlog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type SamplingConfig struct {
+	// Interval is the window in which records are counted. Defaults to 1s.
+	Interval time.Duration
+
+	// First is the number of records with the same level and format logged
+	// in each interval.
+	First int
+
+	// Thereafter logs every Mth record after the first ones in the interval.
+	// Zero drops all of them.
+	Thereafter int
+}
+
+type samplingKey struct {
+	level  int
+	format string
+}
+
+type samplingCount struct {
+	start time.Time
+	n     int
+}
+
+// sampler limits records per level and format and reports what it dropped
+// in a summary once per interval. Fatal records are never sampled.
+type sampler struct {
+	config     SamplingConfig
+	emit       func(r *Record)
+	now        func() time.Time
+	counts     map[samplingKey]*samplingCount
+	suppressed map[samplingKey]int
+	timer      *time.Timer
+	mu         sync.Mutex
+}
+
+func newSampler(config *SamplingConfig, emit func(r *Record)) *sampler {
+	s := &sampler{
+		config:     *config,
+		emit:       emit,
+		now:        time.Now,
+		counts:     make(map[samplingKey]*samplingCount),
+		suppressed: make(map[samplingKey]int),
+	}
+	if s.config.Interval <= 0 {
+		s.config.Interval = time.Second
+	}
+	return s
+}
+
+// allow reports whether a record should be logged.
+func (s *sampler) allow(level int, format string) bool {
+	if level == Fatal {
+		return true
+	}
+	key := samplingKey{level, format}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counts[key]
+	if !ok || now.Sub(c.start) >= s.config.Interval {
+		if len(s.counts) >= 10000 {
+			s.counts = make(map[samplingKey]*samplingCount)
+		}
+		c = &samplingCount{start: now}
+		s.counts[key] = c
+	}
+	c.n++
+	if c.n <= s.config.First {
+		return true
+	}
+	if s.config.Thereafter > 0 && (c.n-s.config.First)%s.config.Thereafter == 0 {
+		return true
+	}
+	s.suppressed[key]++
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.config.Interval, s.report)
+	}
+	return false
+}
+
+// report writes a summary record for every level and format that had
+// records suppressed since the last report.
+func (s *sampler) report() {
+	s.mu.Lock()
+	suppressed := s.suppressed
+	s.suppressed = make(map[samplingKey]int)
+	s.timer = nil
+	s.mu.Unlock()
+
+	keys := make([]samplingKey, 0, len(suppressed))
+	for k := range suppressed {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return suppressed[keys[i]] > suppressed[keys[j]]
+	})
+	now := s.now()
+	for _, k := range keys {
+		s.emit(&Record{
+			Time:    now,
+			Level:   Warn,
+			Message: "nlog: suppressed log records",
+			Fields: []Field{
+				{"level", LevelToName(k.level)},
+				{"format", k.format},
+				{"count", suppressed[k]},
+			},
+		})
+	}
+}
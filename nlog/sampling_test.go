@@ -0,0 +1,62 @@
+package nlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	config := Config{
+		Out:      &buf,
+		Level:    Info,
+		Sampling: &SamplingConfig{Interval: time.Hour, First: 2, Thereafter: 3},
+	}
+	l := NewLogger(&config).(*myLogger)
+	now := time.Now()
+	l.core.sampler.now = func() time.Time { return now }
+
+	for i := 1; i <= 10; i++ {
+		l.Errorf("failed %d", i)
+	}
+	l.Info("other")
+	e := "[ERROR] failed 1\n[ERROR] failed 2\n[ERROR] failed 5\n[ERROR] failed 8\n[INFO] other\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	buf.Reset()
+	l.core.sampler.report()
+	e = "[WARN] nlog: suppressed log records level=ERROR format=\"failed %d\" count=6\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	// a new interval starts counting again
+	buf.Reset()
+	now = now.Add(time.Hour)
+	l.Errorf("failed %d", 11)
+	if r := buf.String(); r != "[ERROR] failed 11\n" {
+		t.Errorf("%s != [ERROR] failed 11", trimLF(r))
+	}
+}
+
+func TestSamplingReport(t *testing.T) {
+	var buf syncBuffer
+	config := Config{
+		Out:      &buf,
+		Level:    Info,
+		Sampling: &SamplingConfig{Interval: 10 * time.Millisecond, First: 1},
+	}
+	l := NewLogger(&config)
+
+	for i := 0; i < 5; i++ {
+		l.Warn("slow")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if r := buf.String(); !strings.Contains(r, "format=slow count=4") {
+		t.Errorf("%s has no summary", trimLF(r))
+	}
+}
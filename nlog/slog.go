@@ -0,0 +1,298 @@
+package nlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// The slog levels for Trace and Fatal, which slog does not define.
+// They sit one step below slog.LevelDebug and above slog.LevelError.
+const (
+	LevelTrace = slog.LevelDebug - 4
+	LevelFatal = slog.LevelError + 4
+)
+
+// SlogLevel maps a level to the slog level scale.
+func SlogLevel(level int) slog.Level {
+	switch level {
+	case Fatal:
+		return LevelFatal
+	case Error:
+		return slog.LevelError
+	case Warn:
+		return slog.LevelWarn
+	case Info:
+		return slog.LevelInfo
+	case Debug:
+		return slog.LevelDebug
+	}
+	return LevelTrace
+}
+
+// FromSlogLevel maps a slog level to the most verbose level that is not
+// more severe than it, so slog.LevelInfo+2 becomes Info.
+func FromSlogLevel(level slog.Level) int {
+	switch {
+	case level >= LevelFatal:
+		return Fatal
+	case level >= slog.LevelError:
+		return Error
+	case level >= slog.LevelWarn:
+		return Warn
+	case level >= slog.LevelInfo:
+		return Info
+	case level >= slog.LevelDebug:
+		return Debug
+	}
+	return Trace
+}
+
+// SlogLevelName returns the name of a slog level, using TRACE and FATAL
+// for LevelTrace and LevelFatal where slog would print DEBUG-4 and ERROR+4.
+func SlogLevelName(level slog.Level) string {
+	switch level {
+	case LevelTrace:
+		return LevelToName(Trace)
+	case LevelFatal:
+		return LevelToName(Fatal)
+	}
+	return level.String()
+}
+
+// ReplaceLevelName can be used as slog.HandlerOptions.ReplaceAttr to make
+// slog handlers print TRACE and FATAL level names. Set the handler's Level
+// to LevelTrace to let trace records through.
+func ReplaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(SlogLevelName(level))
+		}
+	}
+	return a
+}
+
+// appendAttr flattens an slog attribute into fields, joining group keys
+// with dots.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{prefix + a.Key, a.Value.Any()})
+}
+
+// recordLogger is implemented by loggers that can write a Record as is,
+// keeping its time, caller and level even when that level is Fatal.
+type recordLogger interface {
+	log(r *Record)
+}
+
+var (
+	_ recordLogger = (*myLogger)(nil)
+	_ recordLogger = (*slogLogger)(nil)
+)
+
+type slogHandler struct {
+	l      Logger
+	prefix string
+}
+
+var _ slog.Handler = (*slogHandler)(nil)
+
+// NewSlogHandler returns an slog.Handler that writes records through l.
+// Records at LevelFatal and above are logged at Fatal without exiting
+// when l was created by this package, and at Error otherwise.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// Enabled is called before the call site is known, so for a logger of this
+// package it allows the levels that SetVModule enables for any file, and
+// Handle applies the overrides for the file of the record.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if l, ok := h.l.(*myLogger); ok {
+		return l.core.enabledAnywhere(FromSlogLevel(level))
+	}
+	return h.l.V(FromSlogLevel(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, sr slog.Record) error {
+	level := FromSlogLevel(sr.Level)
+	if l, ok := h.l.(*myLogger); ok && !l.core.enabledPC(level, sr.PC) {
+		return nil
+	}
+	fields := make([]Field, 0, sr.NumAttrs())
+	sr.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	if rl, ok := h.l.(recordLogger); ok {
+		rl.log(&Record{
+			Time:    sr.Time,
+			Level:   level,
+			Message: sr.Message,
+			PC:      sr.PC,
+			Fields:  fields,
+		})
+		return nil
+	}
+	kv := make([]interface{}, len(fields))
+	for i, f := range fields {
+		kv[i] = f
+	}
+	switch level {
+	case Trace:
+		h.l.Trace(sr.Message, kv...)
+	case Debug:
+		h.l.Debug(sr.Message, kv...)
+	case Info:
+		h.l.Info(sr.Message, kv...)
+	case Warn:
+		h.l.Warn(sr.Message, kv...)
+	default:
+		h.l.Error(sr.Message, kv...)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	kv := make([]interface{}, len(fields))
+	for i, f := range fields {
+		kv[i] = f
+	}
+	return &slogHandler{l: h.l.With(kv...), prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, prefix: h.prefix + name + "."}
+}
+
+type slogLogger struct {
+	h slog.Handler
+}
+
+var _ Logger = (*slogLogger)(nil)
+
+// NewSlogLogger returns a Logger that writes records to an slog.Handler.
+func NewSlogLogger(h slog.Handler) Logger {
+	return &slogLogger{h: h}
+}
+
+func (l *slogLogger) V(level int) bool {
+	return level != No && l.h.Enabled(context.Background(), SlogLevel(level))
+}
+
+func (l *slogLogger) Tracef(format string, v ...interface{}) {
+	l.logf(Trace, format, v...)
+}
+
+func (l *slogLogger) Debugf(format string, v ...interface{}) {
+	l.logf(Debug, format, v...)
+}
+
+func (l *slogLogger) Infof(format string, v ...interface{}) {
+	l.logf(Info, format, v...)
+}
+
+func (l *slogLogger) Warnf(format string, v ...interface{}) {
+	l.logf(Warn, format, v...)
+}
+
+func (l *slogLogger) Errorf(format string, v ...interface{}) {
+	l.logf(Error, format, v...)
+}
+
+func (l *slogLogger) Fatalf(format string, v ...interface{}) {
+	l.logf(Fatal, format, v...)
+	os.Exit(1)
+}
+
+func (l *slogLogger) Trace(msg string, kv ...interface{}) {
+	l.logkv(Trace, msg, kv)
+}
+
+func (l *slogLogger) Debug(msg string, kv ...interface{}) {
+	l.logkv(Debug, msg, kv)
+}
+
+func (l *slogLogger) Info(msg string, kv ...interface{}) {
+	l.logkv(Info, msg, kv)
+}
+
+func (l *slogLogger) Warn(msg string, kv ...interface{}) {
+	l.logkv(Warn, msg, kv)
+}
+
+func (l *slogLogger) Error(msg string, kv ...interface{}) {
+	l.logkv(Error, msg, kv)
+}
+
+func (l *slogLogger) Fatal(msg string, kv ...interface{}) {
+	l.logkv(Fatal, msg, kv)
+	os.Exit(1)
+}
+
+func (l *slogLogger) With(kv ...interface{}) Logger {
+	return &slogLogger{h: l.h.WithAttrs(toAttrs(toFields(kv)))}
+}
+
+func (l *slogLogger) logf(level int, format string, v ...interface{}) {
+	if !l.V(level) {
+		return
+	}
+	l.output(level, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *slogLogger) logkv(level int, msg string, kv []interface{}) {
+	if !l.V(level) {
+		return
+	}
+	l.output(level, msg, toFields(kv))
+}
+
+func (l *slogLogger) output(level int, msg string, fields []Field) {
+	var pcs [1]uintptr
+	// skip runtime.Callers, output, logkv/logf and the exported method
+	runtime.Callers(4, pcs[:])
+	l.log(&Record{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		PC:      pcs[0],
+		Fields:  fields,
+	})
+}
+
+func (l *slogLogger) log(r *Record) {
+	sr := slog.NewRecord(r.Time, SlogLevel(r.Level), r.Message, r.PC)
+	sr.AddAttrs(toAttrs(r.Fields)...)
+	l.h.Handle(context.Background(), sr)
+}
+
+func toAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	return attrs
+}
@@ -0,0 +1,80 @@
+package nlog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	config := Config{Out: &buf, Level: Info}
+	sl := slog.New(NewSlogHandler(NewLogger(&config)))
+
+	sl.Debug("hidden")
+	sl.With("request", "abc").WithGroup("db").Info("query", "rows", 3, slog.Group("tx", "id", 7))
+	e := "[INFO] query request=abc db.rows=3 db.tx.id=7\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{AddSource: true})
+	l := NewSlogLogger(h).With("request", "abc")
+
+	if l.V(Debug) || !l.V(Info) {
+		t.Error("V does not follow the handler level")
+	}
+	l.Debugf("hidden")
+	l.Warnf("slow %d", 2)
+	r := buf.String()
+	for _, e := range []string{"level=WARN", "source=", "slog_test.go:", `msg="slow 2"`, "request=abc"} {
+		if !strings.Contains(r, e) {
+			t.Errorf("%s does not contain %s", trimLF(r), e)
+		}
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for level := Fatal; level <= Trace; level++ {
+		if r := FromSlogLevel(SlogLevel(level)); r != level {
+			t.Errorf("%s round-trips to %s", LevelToName(level), LevelToName(r))
+		}
+	}
+	if r := FromSlogLevel(slog.LevelInfo + 2); r != Info {
+		t.Errorf("INFO+2 maps to %s", LevelToName(r))
+	}
+	if r := SlogLevelName(LevelTrace); r != "TRACE" {
+		t.Errorf("%s != TRACE", r)
+	}
+	if r := SlogLevelName(slog.LevelWarn); r != "WARN" {
+		t.Errorf("%s != WARN", r)
+	}
+}
+
+func TestSlogLevelNameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level:       LevelTrace,
+		ReplaceAttr: ReplaceLevelName,
+	})
+	l := NewSlogLogger(h)
+	l.Tracef("trace")
+	if r := buf.String(); !strings.Contains(r, "level=TRACE") {
+		t.Errorf("%s does not contain level=TRACE", trimLF(r))
+	}
+
+	// nlog -> slog -> nlog keeps both ends of the scale
+	buf.Reset()
+	config := Config{Out: &buf, Level: Trace}
+	l = NewSlogLogger(NewSlogHandler(NewLogger(&config)))
+	l.Trace("trace")
+	slog.New(NewSlogHandler(l)).Log(nil, LevelFatal, "fatal")
+	e := "[TRACE] trace\n[FATAL] fatal\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
@@ -0,0 +1,186 @@
+package nlog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Facility is the syslog facility of records.
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+)
+
+const (
+	FacilityLocal0 Facility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// syslog severities
+const (
+	sevEmerg = iota
+	sevAlert
+	sevCrit
+	sevErr
+	sevWarning
+	sevNotice
+	sevInfo
+	sevDebug
+)
+
+// SyslogSeverity maps a level to a syslog severity, which is also the
+// journald priority. Records without a level are notices.
+func SyslogSeverity(level int) int {
+	switch level {
+	case Fatal:
+		return sevCrit
+	case Error:
+		return sevErr
+	case Warn:
+		return sevWarning
+	case Info:
+		return sevInfo
+	case Debug, Trace:
+		return sevDebug
+	}
+	return sevNotice
+}
+
+var syslogAddrs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type SyslogConfig struct {
+	// Addr is the path of the syslog socket. If empty, the usual paths
+	// such as /dev/log are tried.
+	Addr string
+
+	// Facility is FacilityUser if zero, as FacilityKern is reserved for
+	// the kernel.
+	Facility Facility
+
+	// Tag defaults to the program name.
+	Tag string
+
+	// Encoder formats the message of records. If nil, messages are written
+	// as "message key=value ...".
+	Encoder Encoder
+}
+
+// SyslogSink writes records to the local syslog over a unix datagram socket.
+type SyslogSink struct {
+	config SyslogConfig
+	conn   net.Conn
+	buf    bytes.Buffer
+	mu     sync.Mutex
+}
+
+var _ Sink = (*SyslogSink)(nil)
+
+func NewSyslogSink(config *SyslogConfig) (*SyslogSink, error) {
+	s := &SyslogSink{config: *config}
+	if s.config.Facility == FacilityKern {
+		s.config.Facility = FacilityUser
+	}
+	if s.config.Tag == "" {
+		s.config.Tag = programName()
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) connect() error {
+	addrs := syslogAddrs
+	if s.config.Addr != "" {
+		addrs = []string{s.config.Addr}
+	}
+	var err error
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = net.Dial("unixgram", addr)
+		if err == nil {
+			s.conn = conn
+			return nil
+		}
+	}
+	return fmt.Errorf("nlog: syslog: %w", err)
+}
+
+// WriteRecord sends a record as a single datagram, reconnecting once if the
+// socket has gone away, as when syslog is restarted.
+func (s *SyslogSink) WriteRecord(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return os.ErrClosed
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	s.buf.Reset()
+	fmt.Fprintf(&s.buf, "<%d>%s %s[%d]: ",
+		int(s.config.Facility)<<3|SyslogSeverity(r.Level),
+		t.Format(time.Stamp), s.config.Tag, os.Getpid())
+	appendMessage(&s.buf, r, s.config.Encoder)
+	if _, err := s.conn.Write(s.buf.Bytes()); err != nil {
+		s.conn.Close()
+		if s.connect() != nil {
+			return err
+		}
+		_, err = s.conn.Write(s.buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// appendMessage appends the message of a record without the level and the
+// trailing newline, formatted by encoder if not nil.
+func appendMessage(buf *bytes.Buffer, r *Record, encoder Encoder) {
+	if encoder != nil {
+		encoder.Encode(buf, r)
+		buf.Truncate(len(bytes.TrimRight(buf.Bytes(), "\n")))
+		return
+	}
+	m := *r
+	m.Level = No
+	buf.WriteString(formatText(&m, 0))
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
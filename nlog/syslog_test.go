@@ -0,0 +1,105 @@
+package nlog
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenUnixgram listens on a temporary unix datagram socket.
+func listenUnixgram(t *testing.T) (*net.UnixConn, string) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, addr
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 65536)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, addr := listenUnixgram(t)
+	s, err := NewSyslogSink(&SyslogConfig{Addr: addr, Facility: FacilityLocal3, Tag: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	l := NewLogger(&Config{Sink: s, Level: Info})
+
+	l.Warn("disk full", "path", "/var")
+	r := readDatagram(t, conn)
+	e := "<156>" // local3 (19) << 3 | warning (4)
+	if !strings.HasPrefix(r, e) {
+		t.Errorf("%s does not start with %s", r, e)
+	}
+	e = " app[" + strconv.Itoa(os.Getpid()) + "]: disk full path=/var"
+	if !strings.HasSuffix(r, e) {
+		t.Errorf("%s does not end with %s", r, e)
+	}
+
+	l.Errorf("failed")
+	if r := readDatagram(t, conn); !strings.HasPrefix(r, "<155>") {
+		t.Errorf("%s does not start with <155>", r)
+	}
+}
+
+func TestSyslogSinkEncoder(t *testing.T) {
+	conn, addr := listenUnixgram(t)
+	s, err := NewSyslogSink(&SyslogConfig{Addr: addr, Encoder: JSONEncoder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.WriteRecord(&Record{Level: Info, Message: "hello", Fields: []Field{{"k", 1}}})
+	r := readDatagram(t, conn)
+	e := "<14>" // user (1) << 3 | info (6)
+	if !strings.HasPrefix(r, e) {
+		t.Errorf("%s does not start with %s", r, e)
+	}
+	e = `]: {"level":"INFO","msg":"hello","k":1}`
+	if !strings.HasSuffix(r, e) {
+		t.Errorf("%s does not end with %s", r, e)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level    int
+		severity int
+	}{
+		{Fatal, 2},
+		{Error, 3},
+		{Warn, 4},
+		{No, 5},
+		{Info, 6},
+		{Debug, 7},
+		{Trace, 7},
+	}
+	for _, test := range tests {
+		if r := SyslogSeverity(test.level); r != test.severity {
+			t.Errorf("%d: %d != %d", test.level, r, test.severity)
+		}
+	}
+}
+
+func TestSyslogSinkNoSocket(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "none.sock")
+	if _, err := NewSyslogSink(&SyslogConfig{Addr: addr}); err == nil {
+		t.Error("no error")
+	}
+}
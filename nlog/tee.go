@@ -0,0 +1,250 @@
+package nlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Target is a destination of a Tee.
+type Target struct {
+	// Out receives records at Level or more severe.
+	Out   io.Writer
+	Level int
+
+	// Encoder formats records written to Out. If nil, records are written
+	// as "[LEVEL] message key=value ..." using Prefix and Flag.
+	Encoder Encoder
+	Prefix  string
+	Flag    int
+
+	// Sink receives records in place of Out and Encoder if not nil.
+	Sink Sink
+}
+
+type TeeConfig struct {
+	Targets []Target
+
+	// QueueSize is the number of records each target can queue while it is
+	// busy, 1024 if zero. Records that do not fit are dropped and the number
+	// dropped is reported to OnError.
+	QueueSize int
+
+	// OnError is called with the index of the target that failed to write
+	// a record. If nil, errors are printed to os.Stderr. It is called from
+	// the goroutines of the targets, one call at a time.
+	OnError func(target int, err error)
+}
+
+// Tee is a Sink that writes each record to the targets whose level enables
+// it, and records without a level to every target. Each target writes from
+// its own goroutine through a bounded queue, so a target that stalls,
+// returns an error or panics does not block or stop the others, nor the
+// logger. Field values are formatted on those goroutines, after the call
+// that logged them returned.
+//
+// Use Tee.Level as the level of the logger so that records reach the most
+// verbose target, and call Flush or Close before the program exits; loggers
+// created by NewLogger flush it before os.Exit.
+//
+//	tee := nlog.NewTee(&nlog.TeeConfig{Targets: targets})
+//	l := nlog.NewLogger(&nlog.Config{Sink: tee, Level: tee.Level()})
+type Tee struct {
+	targets []*teeTarget
+	onError func(target int, err error)
+	errMu   sync.Mutex
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type teeTarget struct {
+	Target
+	logger  *log.Logger
+	buf     bytes.Buffer
+	queue   chan teeItem
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// teeItem is a record to write, or a marker that is closed once the records
+// queued before it are written.
+type teeItem struct {
+	r       *Record
+	flushed chan struct{}
+}
+
+var (
+	_ Sink    = (*Tee)(nil)
+	_ flusher = (*Tee)(nil)
+)
+
+func NewTee(config *TeeConfig) *Tee {
+	t := &Tee{onError: config.OnError}
+	if t.onError == nil {
+		t.onError = func(target int, err error) {
+			fmt.Fprintf(os.Stderr, "nlog: tee target %d: %v\n", target, err)
+		}
+	}
+	size := config.QueueSize
+	if size <= 0 {
+		size = 1024
+	}
+	for i, target := range config.Targets {
+		tt := &teeTarget{
+			Target: target,
+			queue:  make(chan teeItem, size),
+			done:   make(chan struct{}),
+		}
+		if tt.Sink == nil && tt.Encoder == nil {
+			tt.logger = log.New(tt.Out, tt.Prefix, tt.Flag&^(Lshortfile|Llongfile))
+		}
+		t.targets = append(t.targets, tt)
+		go t.run(i, tt)
+	}
+	return t
+}
+
+// Level returns the most verbose level of the targets.
+func (t *Tee) Level() int {
+	level := No
+	for _, target := range t.targets {
+		if target.Level > level {
+			level = target.Level
+		}
+	}
+	return level
+}
+
+// WriteRecord queues a record for the targets without waiting for them to
+// write it. Errors of the targets are reported to OnError.
+func (t *Tee) WriteRecord(r *Record) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return os.ErrClosed
+	}
+	for _, target := range t.targets {
+		if r.Level > target.Level {
+			continue
+		}
+		select {
+		case target.queue <- teeItem{r: r}:
+		default:
+			target.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Flush waits until the targets have written the records queued before the
+// call, then flushes the outputs and sinks of the targets that buffer
+// writes, such as AsyncWriter, returning the first error.
+func (t *Tee) Flush() error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return os.ErrClosed
+	}
+	markers := make([]chan struct{}, len(t.targets))
+	for i, target := range t.targets {
+		markers[i] = make(chan struct{})
+		target.queue <- teeItem{flushed: markers[i]}
+	}
+	t.mu.RUnlock()
+	for _, ch := range markers {
+		<-ch
+	}
+	return t.flushTargets()
+}
+
+// Close writes the queued records, stops the goroutines of the targets and
+// flushes them as Flush does. It does not close the targets. Records written
+// after Close are rejected with os.ErrClosed.
+func (t *Tee) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return os.ErrClosed
+	}
+	t.closed = true
+	for _, target := range t.targets {
+		close(target.queue)
+	}
+	t.mu.Unlock()
+	for _, target := range t.targets {
+		<-target.done
+	}
+	return t.flushTargets()
+}
+
+func (t *Tee) flushTargets() error {
+	var first error
+	for i, target := range t.targets {
+		var out interface{} = target.Out
+		if target.Sink != nil {
+			out = target.Sink
+		}
+		f, ok := out.(flusher)
+		if !ok {
+			continue
+		}
+		if err := f.Flush(); err != nil {
+			t.report(i, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// run writes the records queued for a target until the Tee is closed.
+func (t *Tee) run(i int, target *teeTarget) {
+	defer close(target.done)
+	for item := range target.queue {
+		t.reportDropped(i, target)
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if err := target.write(item.r); err != nil {
+			t.report(i, err)
+		}
+	}
+	t.reportDropped(i, target)
+}
+
+func (t *Tee) reportDropped(i int, target *teeTarget) {
+	if n := target.dropped.Swap(0); n > 0 {
+		t.report(i, fmt.Errorf("nlog: dropped %d records", n))
+	}
+}
+
+func (t *Tee) report(i int, err error) {
+	t.errMu.Lock()
+	defer t.errMu.Unlock()
+	t.onError(i, err)
+}
+
+func (t *teeTarget) write(r *Record) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("nlog: target panic: %v", v)
+		}
+	}()
+	if t.Sink != nil {
+		return t.Sink.WriteRecord(r)
+	}
+	if t.logger != nil {
+		return t.logger.Output(0, formatText(r, t.Flag))
+	}
+	t.buf.Reset()
+	t.Encoder.Encode(&t.buf, r)
+	_, err = t.Out.Write(t.buf.Bytes())
+	return err
+}
@@ -0,0 +1,169 @@
+package nlog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+type errorWriter struct{}
+
+func (errorWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+type panicSink struct{}
+
+func (panicSink) WriteRecord(r *Record) error {
+	panic("broken")
+}
+
+func TestTee(t *testing.T) {
+	var errBuf, jsonBuf, traceBuf bytes.Buffer
+	var failed []int
+	var reported []string
+	tee := NewTee(&TeeConfig{
+		Targets: []Target{
+			{Out: errorWriter{}, Level: Error},
+			{Out: &errBuf, Level: Error},
+			{Out: &jsonBuf, Level: Info, Encoder: JSONEncoder{}},
+			{Sink: panicSink{}, Level: Debug},
+			{Out: &traceBuf, Level: Trace},
+		},
+		OnError: func(target int, err error) {
+			failed = append(failed, target)
+			reported = append(reported, err.Error())
+		},
+	})
+	if tee.Level() != Trace {
+		t.Errorf("%d != %d", tee.Level(), Trace)
+	}
+	l := NewLogger(&Config{Sink: tee, Level: tee.Level()})
+
+	l.Error("failed", "k", 1)
+	l.Info("saved")
+	l.Trace("step")
+	if err := tee.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	e := "[ERROR] failed k=1\n"
+	if r := errBuf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+	if r := traceBuf.String(); r != "[ERROR] failed k=1\n[INFO] saved\n[TRACE] step\n" {
+		t.Errorf("%s", trimLF(r))
+	}
+	if n := bytes.Count(jsonBuf.Bytes(), []byte("\n")); n != 2 {
+		t.Errorf("%d != 2", n)
+	}
+	if !bytes.Contains(jsonBuf.Bytes(), []byte(`"level":"INFO"`)) {
+		t.Errorf("%s", trimLF(jsonBuf.String()))
+	}
+
+	// the targets write from their own goroutines, so only the order of
+	// the errors of each target is known
+	errs := map[int][]string{}
+	for i, target := range failed {
+		errs[target] = append(errs[target], reported[i])
+	}
+	if r := errs[0]; len(r) != 1 || r[0] != "disk full" {
+		t.Errorf("%v", r)
+	}
+	if r := errs[3]; len(r) != 2 || r[0] != "nlog: target panic: broken" {
+		t.Errorf("%v", r)
+	}
+	if len(errs) != 2 {
+		t.Errorf("%v", errs)
+	}
+}
+
+// blockingWriter blocks writes until unblock is closed, signaling started
+// when the first write begins.
+type blockingWriter struct {
+	started chan struct{}
+	unblock chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.unblock
+	return w.buf.Write(p)
+}
+
+func TestTeeBlockedTarget(t *testing.T) {
+	slow := &blockingWriter{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	var fast bytes.Buffer
+	var reported []string
+	tee := NewTee(&TeeConfig{
+		Targets:   []Target{{Out: slow, Level: Info}, {Out: &fast, Level: Info}},
+		QueueSize: 2,
+		OnError: func(target int, err error) {
+			if target == 0 {
+				reported = append(reported, err.Error())
+			}
+		},
+	})
+	l := NewLogger(&Config{Sink: tee, Level: tee.Level()})
+	l.Info("record 0")
+	<-slow.started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i < 10; i++ {
+			l.Infof("record %d", i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a stalled target")
+	}
+
+	close(slow.unblock)
+	if err := tee.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the other target may drop records of the burst too
+	if n := strings.Count(fast.String(), "\n"); n < 2 {
+		t.Errorf("%d records written to the other target", n)
+	}
+	// the stalled target holds one record and queues two
+	if n := strings.Count(slow.buf.String(), "\n"); n != 3 {
+		t.Errorf("%d != 3: %s", n, slow.buf.String())
+	}
+	if len(reported) != 1 || reported[0] != "nlog: dropped 7 records" {
+		t.Errorf("%v", reported)
+	}
+	if err := tee.WriteRecord(&Record{Level: Info, Message: "late"}); err != os.ErrClosed {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestTeeFatal(t *testing.T) {
+	if os.Getenv("NLOG_TEST_FATAL") == "1" {
+		w := NewAsyncWriter(&AsyncConfig{Out: os.Stdout})
+		tee := NewTee(&TeeConfig{Targets: []Target{{Out: w, Level: Info}}})
+		l := NewLogger(&Config{Sink: tee, Level: tee.Level()})
+		l.Fatal("bye", "code", 2)
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTeeFatal$")
+	cmd.Env = append(os.Environ(), "NLOG_TEST_FATAL=1")
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("process exited with %v", err)
+	}
+	if string(out) != "[FATAL] bye code=2\n" {
+		t.Errorf("%q != %q", out, "[FATAL] bye code=2\n")
+	}
+}
@@ -0,0 +1,149 @@
+package nlog
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// vmodule holds the overrides set by SetVModule, or nil if there are none.
+var vmodule atomic.Pointer[vmoduleSpec]
+
+type vmoduleRule struct {
+	pattern string
+	level   int
+}
+
+type vmoduleSpec struct {
+	spec  string
+	rules []vmoduleRule
+	files sync.Map // call site pc -> overriding level, or -1
+}
+
+// SetVModule overrides the level of loggers by name or by the source file
+// they are called from. The spec is a comma-separated list of pattern=level
+// pairs, such as "sqlutil=debug,httputil/*=trace", where the level is a
+// level name or number. A pattern matches a logger whose Config.Name matches
+// it, or a call site whose file name without ".go", or whose package
+// directory, matches it. Patterns containing slashes match the trailing
+// path elements of the file, so "httputil/*" matches every file in httputil.
+// The first matching pattern wins. An empty spec removes all overrides.
+func SetVModule(spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		vmodule.Store(nil)
+		return nil
+	}
+	s := &vmoduleSpec{spec: spec}
+	for _, part := range strings.Split(spec, ",") {
+		pattern, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || pattern == "" {
+			return fmt.Errorf("nlog: invalid vmodule %q", part)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("nlog: invalid vmodule pattern %q: %v", pattern, err)
+		}
+		level := NameToLevel(value)
+		if level == No {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < No || n > Trace {
+				return fmt.Errorf("nlog: invalid vmodule level %q", value)
+			}
+			level = n
+		}
+		s.rules = append(s.rules, vmoduleRule{pattern, level})
+	}
+	vmodule.Store(s)
+	return nil
+}
+
+// VModule returns the spec last set by SetVModule.
+func VModule() string {
+	if s := vmodule.Load(); s != nil {
+		return s.spec
+	}
+	return ""
+}
+
+// level returns the overriding level for a logger name, or for the call site
+// depth frames above level.
+func (s *vmoduleSpec) level(name string, depth int) (int, bool) {
+	if level, ok := s.nameLevel(name); ok {
+		return level, true
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(depth+1, pcs[:]) == 0 {
+		return 0, false
+	}
+	return s.pcLevel(pcs[0])
+}
+
+// levelPC is like level for the call site at pc, which is zero if unknown.
+func (s *vmoduleSpec) levelPC(name string, pc uintptr) (int, bool) {
+	if level, ok := s.nameLevel(name); ok {
+		return level, true
+	}
+	if pc == 0 {
+		return 0, false
+	}
+	return s.pcLevel(pc)
+}
+
+func (s *vmoduleSpec) nameLevel(name string) (int, bool) {
+	if name != "" {
+		for _, r := range s.rules {
+			if ok, _ := path.Match(r.pattern, name); ok {
+				return r.level, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (s *vmoduleSpec) pcLevel(pc uintptr) (int, bool) {
+	if v, ok := s.files.Load(pc); ok {
+		level := v.(int)
+		return level, level >= 0
+	}
+	level := s.fileLevel(pc)
+	s.files.Store(pc, level)
+	return level, level >= 0
+}
+
+// maxLevel returns the most verbose level of the rules.
+func (s *vmoduleSpec) maxLevel() int {
+	level := No
+	for _, r := range s.rules {
+		level = max(level, r.level)
+	}
+	return level
+}
+
+func (s *vmoduleSpec) fileLevel(pc uintptr) int {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return -1
+	}
+	file := strings.TrimSuffix(filepath.ToSlash(frame.File), ".go")
+	elems := strings.Split(file, "/")
+	for _, r := range s.rules {
+		n := strings.Count(r.pattern, "/") + 1
+		if n > len(elems) {
+			continue
+		}
+		if ok, _ := path.Match(r.pattern, strings.Join(elems[len(elems)-n:], "/")); ok {
+			return r.level
+		}
+		if n == 1 && len(elems) > 1 {
+			if ok, _ := path.Match(r.pattern, elems[len(elems)-2]); ok {
+				return r.level
+			}
+		}
+	}
+	return -1
+}
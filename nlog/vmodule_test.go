@@ -0,0 +1,100 @@
+package nlog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestVModuleName(t *testing.T) {
+	defer SetVModule("")
+
+	var buf bytes.Buffer
+	db := NewLogger(&Config{Out: &buf, Level: Warn, Name: "db"})
+	web := NewLogger(&Config{Out: &buf, Level: Warn, Name: "web/api"})
+
+	if err := SetVModule("db=debug, web/*=error"); err != nil {
+		t.Fatal(err)
+	}
+	if VModule() != "db=debug, web/*=error" {
+		t.Errorf("VModule returned %q", VModule())
+	}
+
+	db.Debugf("db debug")
+	db.Tracef("db trace")
+	web.Warnf("web warn")
+	web.Errorf("web error")
+	e := "[DEBUG] db debug\n[ERROR] web error\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	buf.Reset()
+	SetVModule("")
+	db.Debugf("db debug")
+	if r := buf.String(); r != "" {
+		t.Errorf("%s != ", trimLF(r))
+	}
+}
+
+func TestVModuleFile(t *testing.T) {
+	defer SetVModule("")
+
+	var buf bytes.Buffer
+	l := NewLogger(&Config{Out: &buf, Level: Warn}).With("k", 1)
+
+	for _, spec := range []string{"vmodule_test=trace", "nlog=trace", "nlog/*=trace", "*_test=6"} {
+		if err := SetVModule(spec); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		l.Debug("debug")
+		if !l.V(Trace) {
+			t.Errorf("%s: V(Trace) is false", spec)
+		}
+		if r := buf.String(); r != "[DEBUG] debug k=1\n" {
+			t.Errorf("%s: %s != [DEBUG] debug k=1", spec, trimLF(r))
+		}
+	}
+
+	SetVModule("other=trace,x/nlog=trace")
+	if l.V(Debug) {
+		t.Error("V(Debug) is true for a file not in vmodule")
+	}
+}
+
+func TestVModuleSlog(t *testing.T) {
+	defer SetVModule("")
+
+	var buf bytes.Buffer
+	l := slog.New(NewSlogHandler(NewLogger(&Config{Out: &buf, Level: Info})))
+
+	// the call site is this file, not the handler in slog.go
+	SetVModule("vmodule_test=debug,slog=error")
+	l.Debug("debug", "k", 1)
+	l.Log(context.Background(), LevelTrace, "trace")
+	e := "[DEBUG] debug k=1\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+
+	buf.Reset()
+	SetVModule("vmodule_test=error,other=trace")
+	l.Warn("warn")
+	l.Error("error")
+	e = "[ERROR] error\n"
+	if r := buf.String(); r != e {
+		t.Errorf("%s != %s", trimLF(r), trimLF(e))
+	}
+}
+
+func TestVModuleInvalid(t *testing.T) {
+	defer SetVModule("")
+
+	for _, spec := range []string{"db", "=debug", "db=loud", "db=7", "[=debug"} {
+		if err := SetVModule(spec); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and timers to a DelayQueue.
+// It can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the subset of time.Timer used by a DelayQueue.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// DelayQueue is a queue whose elements can be popped only once their
+// scheduled time has come. Elements are popped in order of that time.
+type DelayQueue[T any] struct {
+	items   delayHeap[T]
+	seq     uint64
+	clock   Clock
+	closed  bool
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+// NewDelay constructs and returns a new DelayQueue using the system clock.
+func NewDelay[T any]() *DelayQueue[T] {
+	return NewDelayWithClock[T](nil)
+}
+
+// NewDelayWithClock constructs and returns a new DelayQueue using the given
+// clock. A nil clock means the system clock.
+func NewDelayWithClock[T any](clock Clock) *DelayQueue[T] {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &DelayQueue[T]{clock: clock}
+}
+
+// Length returns the number of elements currently stored in the queue,
+// including the ones that are not due yet.
+func (q *DelayQueue[T]) Length() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close marks the queue as closed and wakes up all blocked callers.
+// Elements already in the queue can still be popped when they are due.
+func (q *DelayQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	broadcast(&q.changed)
+}
+
+// Add puts an element on the queue that becomes due after delay.
+// This call panics if the queue is closed.
+func (q *DelayQueue[T]) Add(elem T, delay time.Duration) {
+	q.AddAt(elem, q.clock.Now().Add(delay))
+}
+
+// AddAt puts an element on the queue that becomes due at the given time.
+// This call panics if the queue is closed.
+func (q *DelayQueue[T]) AddAt(elem T, at time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		panic(ErrClosed.Error())
+	}
+	heap.Push(&q.items, &delayItem[T]{value: elem, at: at, seq: q.seq})
+	q.seq++
+	broadcast(&q.changed)
+}
+
+// TryPop removes and returns the earliest element if it is due.
+// The second result is false if no element is due.
+func (q *DelayQueue[T]) TryPop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) <= 0 || q.items[0].at.After(q.clock.Now()) {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&q.items).(*delayItem[T]).value, true
+}
+
+// PopWait removes and returns the earliest element, waiting until it is due.
+// It returns ErrClosed once the queue is closed and drained, or the context's
+// error if ctx is done first.
+func (q *DelayQueue[T]) PopWait(ctx context.Context) (T, error) {
+	var zero T
+	q.mu.Lock()
+	for {
+		var timer Timer
+		var timeout <-chan time.Time
+		if len(q.items) > 0 {
+			d := q.items[0].at.Sub(q.clock.Now())
+			if d <= 0 {
+				defer q.mu.Unlock()
+				return heap.Pop(&q.items).(*delayItem[T]).value, nil
+			}
+			timer = q.clock.NewTimer(d)
+			timeout = timer.C()
+		} else if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+		ch := waiter(&q.changed)
+		q.mu.Unlock()
+		select {
+		case <-ch:
+		case <-timeout:
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return zero, ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		q.mu.Lock()
+	}
+}
+
+type delayItem[T any] struct {
+	value T
+	at    time.Time
+	seq   uint64
+}
+
+// delayHeap implements heap.Interface for DelayQueue.
+type delayHeap[T any] []*delayItem[T]
+
+func (h delayHeap[T]) Len() int { return len(h) }
+
+func (h delayHeap[T]) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h delayHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *delayHeap[T]) Push(x interface{}) {
+	*h = append(*h, x.(*delayItem[T]))
+}
+
+func (h *delayHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
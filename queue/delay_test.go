@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves by Advance.
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	mu     sync.Mutex
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1000000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+		} else {
+			t.c <- c.now
+		}
+	}
+	c.timers = timers
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	return true
+}
+
+func TestDelayQueueTryPop(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayWithClock[string](c)
+	q.Add("b", 2*time.Second)
+	q.Add("a", time.Second)
+
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop returned an element before it is due")
+	}
+
+	c.Advance(time.Second)
+	if v, ok := q.TryPop(); !ok || v != "a" {
+		t.Error("TryPop returned", v, ok)
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop returned an element before it is due")
+	}
+	if q.Length() != 1 {
+		t.Error("queue has length", q.Length())
+	}
+}
+
+func TestDelayQueuePopWait(t *testing.T) {
+	c := newFakeClock()
+	q := NewDelayWithClock[string](c)
+	q.Add("a", time.Minute)
+
+	done := make(chan string)
+	go func() {
+		v, _ := q.PopWait(context.Background())
+		done <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case v := <-done:
+		t.Fatal("PopWait returned", v, "before it is due")
+	default:
+	}
+
+	c.Advance(time.Minute)
+	if v := <-done; v != "a" {
+		t.Error("PopWait returned", v)
+	}
+}
+
+func TestDelayQueuePopWaitContext(t *testing.T) {
+	q := NewDelayWithClock[int](newFakeClock())
+	q.Add(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.PopWait(ctx); err != context.DeadlineExceeded {
+		t.Error("PopWait returned", err)
+	}
+}
+
+func TestDelayQueueClose(t *testing.T) {
+	q := NewDelay[int]()
+	q.Add(1, 0)
+	q.Close()
+
+	if v, err := q.PopWait(context.Background()); err != nil || v != 1 {
+		t.Error("PopWait returned", v, err)
+	}
+	if _, err := q.PopWait(context.Background()); err != ErrClosed {
+		t.Error("PopWait on closed queue returned", err)
+	}
+}
@@ -0,0 +1,311 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/najeira/goutils/metrics"
+	"github.com/najeira/goutils/nlog"
+)
+
+// Handler processes a single job. It should return promptly once ctx is done.
+type Handler[T any] func(ctx context.Context, job T) error
+
+// DispatcherConfig configures a Dispatcher. The zero value runs one worker
+// on an unbounded queue and sends failed jobs straight to the dead letters.
+type DispatcherConfig struct {
+	// Workers is the initial number of worker goroutines. Defaults to 1.
+	Workers int
+
+	// Capacity bounds the job queue. Zero means unbounded.
+	Capacity int
+
+	// Timeout limits each handler call. Zero means no limit.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a failed job is retried before it is
+	// moved to the dead letters.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry. It doubles on every
+	// further attempt up to MaxRetryDelay. Defaults to 100ms.
+	RetryDelay time.Duration
+
+	// MaxRetryDelay caps the retry delay. Zero means no cap.
+	MaxRetryDelay time.Duration
+
+	// Metrics receives queue depth, throughput and handler latency.
+	// A new MetricsQueue is created if nil.
+	Metrics *metrics.MetricsQueue
+}
+
+// Failure is a job that failed all of its attempts.
+type Failure[T any] struct {
+	Job      T
+	Err      error
+	Attempts int
+}
+
+type task[T any] struct {
+	job      T
+	attempts int
+	logger   nlog.Logger
+}
+
+// Dispatcher runs a pool of workers that call a Handler for every job put
+// on its queue.
+type Dispatcher[T any] struct {
+	handler Handler[T]
+	config  DispatcherConfig
+	metrics *metrics.MetricsQueue
+
+	queue   *Queue[*task[T]]
+	retries *DelayQueue[*task[T]]
+	dead    *Queue[*Failure[T]]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	workers  []context.CancelFunc
+	running  sync.WaitGroup
+	pending  int
+	idle     chan struct{}
+	stopping bool
+	stopped  bool
+	mu       sync.Mutex
+}
+
+// NewDispatcher constructs a Dispatcher and starts its workers.
+func NewDispatcher[T any](handler Handler[T], config *DispatcherConfig) *Dispatcher[T] {
+	var c DispatcherConfig
+	if config != nil {
+		c = *config
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = 100 * time.Millisecond
+	}
+	if c.Metrics == nil {
+		c.Metrics = metrics.NewMetricsQueue()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher[T]{
+		handler: handler,
+		config:  c,
+		metrics: c.Metrics,
+		queue:   NewBoundedOf[*task[T]](c.Capacity),
+		retries: NewDelay[*task[T]](),
+		dead:    NewOf[*Failure[T]](),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	d.running.Add(1)
+	go d.retry()
+	d.Resize(c.Workers)
+	return d
+}
+
+// Metrics returns the metrics the dispatcher reports to.
+func (d *Dispatcher[T]) Metrics() *metrics.MetricsQueue {
+	return d.metrics
+}
+
+// DeadLetters returns the queue of jobs that failed all of their attempts.
+func (d *Dispatcher[T]) DeadLetters() *Queue[*Failure[T]] {
+	return d.dead
+}
+
+// Length returns the number of jobs waiting for a worker.
+func (d *Dispatcher[T]) Length() int {
+	return d.queue.Length()
+}
+
+// Workers returns the current number of workers.
+func (d *Dispatcher[T]) Workers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.workers)
+}
+
+// Resize changes the number of workers. Surplus workers exit after
+// finishing their current job. Resizing to zero pauses the dispatcher: jobs
+// stay queued until workers are added again, or until Stop, which starts a
+// worker to finish them. Resize does nothing once stopped.
+func (d *Dispatcher[T]) Resize(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	if d.stopping && n < 1 {
+		n = 1 // keep a worker to finish the jobs Stop waits for
+	}
+	for len(d.workers) < n {
+		d.addWorker()
+	}
+	for len(d.workers) > n && len(d.workers) > 0 {
+		last := len(d.workers) - 1
+		d.workers[last]()
+		d.workers[last] = nil
+		d.workers = d.workers[:last]
+	}
+}
+
+// Submit puts a job on the queue, waiting for room if the queue is bounded
+// and full. It returns ErrClosed once Stop has been called, or the context's
+// error if ctx is done first. The logger of ctx is passed to the handler in
+// its context and logs the failures of the job.
+func (d *Dispatcher[T]) Submit(ctx context.Context, job T) error {
+	d.mu.Lock()
+	if d.stopping {
+		d.mu.Unlock()
+		return ErrClosed
+	}
+	d.pending++
+	d.mu.Unlock()
+
+	if err := d.queue.AddWait(ctx, &task[T]{job: job, logger: nlog.FromContext(ctx)}); err != nil {
+		d.done()
+		return err
+	}
+	d.metrics.UpdateDepth(d.queue.Length())
+	return nil
+}
+
+// Stop stops accepting jobs and waits until every submitted job, including
+// pending retries, has finished, starting a worker if the dispatcher was
+// resized to zero. If ctx is done first, the contexts of running
+// handlers are canceled, remaining jobs are dropped and the context's error
+// is returned. Stop returns after all workers have exited.
+func (d *Dispatcher[T]) Stop(ctx context.Context) error {
+	var err error
+	d.mu.Lock()
+	d.stopping = true
+	if len(d.workers) == 0 && d.pending > 0 {
+		d.addWorker()
+	}
+	for err == nil && d.pending > 0 {
+		ch := waiter(&d.idle)
+		d.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		d.mu.Lock()
+	}
+	d.stopped = true
+	d.workers = nil
+	d.mu.Unlock()
+
+	d.cancel()
+	d.queue.Close()
+	d.retries.Close()
+	d.running.Wait()
+	return err
+}
+
+// addWorker starts a worker. d.mu must be held.
+func (d *Dispatcher[T]) addWorker() {
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.workers = append(d.workers, cancel)
+	d.running.Add(1)
+	go d.work(ctx)
+}
+
+func (d *Dispatcher[T]) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending--
+	if d.pending <= 0 {
+		broadcast(&d.idle)
+	}
+}
+
+func (d *Dispatcher[T]) work(ctx context.Context) {
+	defer d.running.Done()
+	for ctx.Err() == nil {
+		t, err := d.queue.PopWait(ctx)
+		if err != nil {
+			return
+		}
+		d.metrics.UpdateDepth(d.queue.Length())
+		d.process(t)
+	}
+}
+
+func (d *Dispatcher[T]) process(t *task[T]) {
+	t.attempts++
+	start := time.Now()
+	err := d.call(t)
+	d.metrics.Measure(time.Since(start))
+	if err == nil {
+		d.metrics.MarkProcessed(1)
+		d.done()
+		return
+	}
+	d.metrics.MarkFailures(1)
+
+	d.mu.Lock()
+	if !d.stopped && t.attempts <= d.config.MaxRetries {
+		delay := d.backoff(t.attempts)
+		d.retries.Add(t, delay)
+		d.mu.Unlock()
+		d.metrics.MarkRetries(1)
+		t.logger.Warn("queue: job failed, retrying", "attempts", t.attempts, "delay", delay, "err", err)
+		return
+	}
+	d.mu.Unlock()
+
+	t.logger.Error("queue: job failed", "attempts", t.attempts, "err", err)
+	d.dead.Add(&Failure[T]{Job: t.job, Err: err, Attempts: t.attempts})
+	d.metrics.MarkDeadLetters(1)
+	d.done()
+}
+
+func (d *Dispatcher[T]) call(t *task[T]) (err error) {
+	ctx := nlog.NewContext(d.ctx, t.logger)
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("queue: handler panic: %v", r)
+		}
+	}()
+	return d.handler(ctx, t.job)
+}
+
+func (d *Dispatcher[T]) backoff(attempts int) time.Duration {
+	max := d.config.MaxRetryDelay
+	delay := d.config.RetryDelay
+	for i := 1; i < attempts && (max <= 0 || delay < max); i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// retry moves jobs from the retry queue back onto the job queue once their
+// backoff has passed.
+func (d *Dispatcher[T]) retry() {
+	defer d.running.Done()
+	for {
+		t, err := d.retries.PopWait(d.ctx)
+		if err != nil {
+			return
+		}
+		if err := d.queue.AddWait(d.ctx, t); err != nil {
+			return
+		}
+		d.metrics.UpdateDepth(d.queue.Length())
+	}
+}
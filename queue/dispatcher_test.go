@@ -0,0 +1,217 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/najeira/goutils/nlog"
+	"github.com/najeira/goutils/nlog/nlogtest"
+)
+
+func TestDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[job] = true
+		return nil
+	}, &DispatcherConfig{Workers: 4})
+
+	for i := 0; i < 100; i++ {
+		if err := d.Submit(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 100 {
+		t.Error("handled", len(seen), "jobs")
+	}
+	if n := d.Metrics().Get()["processed_count"]; n != 100 {
+		t.Error("processed_count is", n)
+	}
+	if err := d.Submit(context.Background(), 0); err != ErrClosed {
+		t.Error("Submit after Stop returned", err)
+	}
+}
+
+func TestDispatcherRetry(t *testing.T) {
+	errFail := errors.New("fail")
+	var calls int32
+	d := NewDispatcher(func(ctx context.Context, job string) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errFail
+		}
+		return nil
+	}, &DispatcherConfig{MaxRetries: 5, RetryDelay: time.Millisecond})
+
+	d.Submit(context.Background(), "a")
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Error("handler called", calls, "times")
+	}
+	if d.DeadLetters().Length() != 0 {
+		t.Error("succeeded job was dead-lettered")
+	}
+}
+
+func TestDispatcherLogger(t *testing.T) {
+	l := nlogtest.New(t)
+	var handled nlog.Logger
+	d := NewDispatcher(func(ctx context.Context, job string) error {
+		handled = nlog.FromContext(ctx)
+		return errors.New("fail")
+	}, &DispatcherConfig{MaxRetries: 1, RetryDelay: time.Millisecond})
+
+	ctx := nlog.NewContext(context.Background(), l.With("request_id", "abc"))
+	d.Submit(ctx, "a")
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l.AssertLogged(t, nlog.Warn, "retrying")
+	l.AssertLogged(t, nlog.Error, "job failed")
+	for _, e := range l.Entries() {
+		if v, _ := e.Field("request_id"); v != "abc" {
+			t.Errorf("%s has no request_id", e)
+		}
+	}
+	if handled == nil {
+		t.Fatal("handler was not called")
+	}
+	handled.Info("in handler")
+	l.AssertLogged(t, nlog.Info, "in handler")
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	errFail := errors.New("fail")
+	d := NewDispatcher(func(ctx context.Context, job string) error {
+		return errFail
+	}, &DispatcherConfig{MaxRetries: 2, RetryDelay: time.Millisecond})
+
+	d.Submit(context.Background(), "a")
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	f, ok := d.DeadLetters().TryPop()
+	if !ok {
+		t.Fatal("failed job was not dead-lettered")
+	}
+	if f.Job != "a" || f.Err != errFail || f.Attempts != 3 {
+		t.Error("dead letter is", f)
+	}
+}
+
+func TestDispatcherPanic(t *testing.T) {
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		panic("boom")
+	}, nil)
+
+	d.Submit(context.Background(), 1)
+	d.Stop(context.Background())
+	f, ok := d.DeadLetters().TryPop()
+	if !ok || f.Err == nil {
+		t.Error("panicking job was not dead-lettered")
+	}
+}
+
+func TestDispatcherTimeout(t *testing.T) {
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, &DispatcherConfig{Timeout: 10 * time.Millisecond})
+
+	d.Submit(context.Background(), 1)
+	d.Stop(context.Background())
+	f, ok := d.DeadLetters().TryPop()
+	if !ok || f.Err != context.DeadlineExceeded {
+		t.Error("timed out job was not dead-lettered")
+	}
+}
+
+func TestDispatcherResize(t *testing.T) {
+	var running, peak int32
+	release := make(chan struct{})
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return nil
+	}, &DispatcherConfig{Workers: 1})
+
+	d.Resize(3)
+	if d.Workers() != 3 {
+		t.Error("dispatcher has", d.Workers(), "workers")
+	}
+	for i := 0; i < 3; i++ {
+		d.Submit(context.Background(), i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&peak) != 3 {
+		t.Error("ran", peak, "jobs concurrently")
+	}
+
+	d.Resize(1)
+	if d.Workers() != 1 {
+		t.Error("dispatcher has", d.Workers(), "workers")
+	}
+	close(release)
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatcherPause(t *testing.T) {
+	var processed int32
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	}, nil)
+
+	d.Resize(0)
+	for i := 0; i < 3; i++ {
+		d.Submit(context.Background(), i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&processed); n != 0 {
+		t.Error("paused dispatcher processed", n, "jobs")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&processed); n != 3 {
+		t.Error("processed", n, "jobs")
+	}
+}
+
+func TestDispatcherStopTimeout(t *testing.T) {
+	d := NewDispatcher(func(ctx context.Context, job int) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil)
+
+	d.Submit(context.Background(), 1)
+	d.Submit(context.Background(), 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Stop(ctx); err != context.DeadlineExceeded {
+		t.Error("Stop returned", err)
+	}
+}
@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Item is a handle to an element stored in a PriorityQueue. It can be
+// passed to Update and Remove while the element is still queued.
+type Item[T any] struct {
+	Value    T
+	priority int
+	seq      uint64
+	index    int
+	mu       *sync.Mutex // of the queue that owns the item
+}
+
+// Priority returns the priority the item is queued with.
+func (it *Item[T]) Priority() int {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.priority
+}
+
+// PriorityQueue is a heap-backed queue that pops the element with the
+// highest priority first. Elements with equal priority are popped in the
+// order they were added.
+type PriorityQueue[T any] struct {
+	items    priorityHeap[T]
+	seq      uint64
+	closed   bool
+	notEmpty chan struct{}
+	mu       sync.Mutex
+}
+
+// NewPriority constructs and returns a new PriorityQueue.
+func NewPriority[T any]() *PriorityQueue[T] {
+	return &PriorityQueue[T]{}
+}
+
+// Length returns the number of elements currently stored in the queue.
+func (q *PriorityQueue[T]) Length() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Close marks the queue as closed and wakes up all blocked callers.
+// Elements already in the queue can still be popped.
+func (q *PriorityQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	broadcast(&q.notEmpty)
+}
+
+// Add puts an element on the queue with the given priority and returns
+// its handle. This call panics if the queue is closed.
+func (q *PriorityQueue[T]) Add(elem T, priority int) *Item[T] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		panic(ErrClosed.Error())
+	}
+	it := &Item[T]{Value: elem, priority: priority, seq: q.seq, mu: &q.mu}
+	q.seq++
+	heap.Push(&q.items, it)
+	broadcast(&q.notEmpty)
+	return it
+}
+
+// Update changes the priority of a queued item. It returns false if the
+// item is no longer in the queue.
+func (q *PriorityQueue[T]) Update(it *Item[T], priority int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.contains(it) {
+		return false
+	}
+	it.priority = priority
+	heap.Fix(&q.items, it.index)
+	return true
+}
+
+// Remove takes a queued item out of the queue. It returns false if the
+// item is no longer in the queue.
+func (q *PriorityQueue[T]) Remove(it *Item[T]) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.contains(it) {
+		return false
+	}
+	heap.Remove(&q.items, it.index)
+	return true
+}
+
+func (q *PriorityQueue[T]) contains(it *Item[T]) bool {
+	return it != nil && it.index >= 0 && it.index < len(q.items) && q.items[it.index] == it
+}
+
+// Peek returns the element with the highest priority. This call panics
+// if the queue is empty.
+func (q *PriorityQueue[T]) Peek() T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) <= 0 {
+		panic("queue: empty queue")
+	}
+	return q.items[0].Value
+}
+
+// Pop removes and returns the element with the highest priority.
+// This call panics if the queue is empty.
+func (q *PriorityQueue[T]) Pop() T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) <= 0 {
+		panic("queue: empty queue")
+	}
+	return heap.Pop(&q.items).(*Item[T]).Value
+}
+
+// TryPop removes and returns the element with the highest priority.
+// The second result is false if the queue is empty.
+func (q *PriorityQueue[T]) TryPop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) <= 0 {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&q.items).(*Item[T]).Value, true
+}
+
+// PopWait removes and returns the element with the highest priority,
+// waiting until one is added if the queue is empty. It returns ErrClosed
+// once the queue is closed and drained, or the context's error if ctx is
+// done first.
+func (q *PriorityQueue[T]) PopWait(ctx context.Context) (T, error) {
+	var zero T
+	q.mu.Lock()
+	for len(q.items) <= 0 {
+		if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+		ch := waiter(&q.notEmpty)
+		q.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+	return heap.Pop(&q.items).(*Item[T]).Value, nil
+}
+
+// priorityHeap implements heap.Interface for PriorityQueue.
+type priorityHeap[T any] []*Item[T]
+
+func (h priorityHeap[T]) Len() int { return len(h) }
+
+func (h priorityHeap[T]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h priorityHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *priorityHeap[T]) Push(x interface{}) {
+	it := x.(*Item[T])
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *priorityHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*h = old[:n-1]
+	return it
+}
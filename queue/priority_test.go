@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := NewPriority[string]()
+	q.Add("low", 1)
+	q.Add("high", 10)
+	q.Add("mid1", 5)
+	q.Add("mid2", 5)
+
+	for _, e := range []string{"high", "mid1", "mid2", "low"} {
+		if v := q.Pop(); v != e {
+			t.Error("popped", v, "want", e)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop on empty queue succeeded")
+	}
+}
+
+func TestPriorityQueueUpdateRemove(t *testing.T) {
+	q := NewPriority[string]()
+	a := q.Add("a", 1)
+	q.Add("b", 2)
+	c := q.Add("c", 3)
+
+	if !q.Update(a, 4) {
+		t.Error("Update failed")
+	}
+	if a.Priority() != 4 {
+		t.Error("item has priority", a.Priority())
+	}
+	if !q.Remove(c) {
+		t.Error("Remove failed")
+	}
+	if q.Remove(c) {
+		t.Error("Remove of a removed item succeeded")
+	}
+
+	if v := q.Pop(); v != "a" {
+		t.Error("popped", v, "want a")
+	}
+	if q.Update(a, 0) {
+		t.Error("Update of a popped item succeeded")
+	}
+	if v := q.Pop(); v != "b" {
+		t.Error("popped", v, "want b")
+	}
+	assertPanics(t, "should panic when peeking empty queue", func() {
+		q.Peek()
+	})
+}
+
+func TestPriorityQueueConcurrentUpdate(t *testing.T) {
+	q := NewPriority[string]()
+	a := q.Add("a", 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 100; i++ {
+			q.Update(a, i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if p := a.Priority(); p < 0 || p > 100 {
+			t.Fatal("item has priority", p)
+		}
+	}
+	<-done
+	if a.Priority() != 100 {
+		t.Error("item has priority", a.Priority())
+	}
+}
+
+func TestPriorityQueuePopWait(t *testing.T) {
+	q := NewPriority[int]()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Add(1, 0)
+		q.Close()
+	}()
+
+	v, err := q.PopWait(context.Background())
+	if err != nil || v != 1 {
+		t.Error("PopWait returned", v, err)
+	}
+	if _, err := q.PopWait(context.Background()); err != ErrClosed {
+		t.Error("PopWait on closed queue returned", err)
+	}
+}
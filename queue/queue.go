@@ -3,32 +3,116 @@
 package queue
 
 import (
+	"context"
+	"errors"
+	"iter"
 	"sync"
 )
 
 const minQueueLen = 16
 
-// Queue represents a single instance of the queue data structure.
-type Queue struct {
-	buf               []interface{}
+var (
+	// ErrFull is returned by TryAdd when a bounded queue is at capacity.
+	ErrFull = errors.New("queue: full queue")
+
+	// ErrClosed is returned when adding to a closed queue, or when waiting
+	// on a queue that is closed and drained.
+	ErrClosed = errors.New("queue: closed queue")
+)
+
+// Interface is the set of non-blocking operations shared by Queue and Ring,
+// so either can be used where contention characteristics differ.
+type Interface[T any] interface {
+	// TryAdd puts an element on the end of the queue, or returns an error
+	// such as ErrFull if it cannot do so without blocking.
+	TryAdd(elem T) error
+
+	// TryPop removes and returns the element at the head of the queue.
+	// The second result is false if the queue is empty.
+	TryPop() (T, bool)
+
+	// Length returns the number of elements currently stored in the queue.
+	Length() int
+}
+
+var (
+	_ Interface[any] = (*Queue[any])(nil)
+	_ Interface[any] = (*Ring[any])(nil)
+)
+
+// Queue represents a single instance of the queue data structure
+// holding elements of type T.
+type Queue[T any] struct {
+	buf               []T
 	head, tail, count int
+	capacity          int
+	closed            bool
+	notEmpty, notFull chan struct{}
 	mu                sync.Mutex
 }
 
-// New constructs and returns a new Queue.
-func New() *Queue {
-	return &Queue{buf: make([]interface{}, minQueueLen)}
+// New constructs and returns a new untyped Queue.
+func New() *Queue[any] {
+	return NewOf[any]()
+}
+
+// NewBounded constructs and returns a new untyped Queue that holds at most
+// capacity elements. A capacity of zero or less means the queue is unbounded.
+func NewBounded(capacity int) *Queue[any] {
+	return NewBoundedOf[any](capacity)
+}
+
+// NewOf constructs and returns a new Queue of elements of type T.
+func NewOf[T any]() *Queue[T] {
+	return NewBoundedOf[T](0)
+}
+
+// NewBoundedOf constructs and returns a new Queue of elements of type T that
+// holds at most capacity elements. A capacity of zero or less means the queue
+// is unbounded.
+func NewBoundedOf[T any](capacity int) *Queue[T] {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &Queue[T]{buf: make([]T, minQueueLen), capacity: capacity}
 }
 
 // Length returns the number of elements currently stored in the queue.
-func (q *Queue) Length() int {
+func (q *Queue[T]) Length() int {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	return q.count
 }
 
-func (q *Queue) resize() {
-	newBuf := make([]interface{}, q.count*2)
+// Capacity returns the maximum number of elements the queue holds,
+// or zero if the queue is unbounded.
+func (q *Queue[T]) Capacity() int {
+	return q.capacity
+}
+
+// Closed reports whether Close has been called.
+func (q *Queue[T]) Closed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+// Close marks the queue as closed and wakes up all blocked callers.
+// Elements already in the queue can still be popped; adding panics or
+// returns ErrClosed. Closing a closed queue does nothing.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	broadcast(&q.notEmpty)
+	broadcast(&q.notFull)
+}
+
+func (q *Queue[T]) resize() {
+	newBuf := make([]T, q.count*2)
 	if q.tail > q.head {
 		copy(newBuf, q.buf[q.head:q.tail])
 	} else {
@@ -40,21 +124,128 @@ func (q *Queue) resize() {
 	q.buf = newBuf
 }
 
-// Add puts an element on the end of the queue.
-func (q *Queue) Add(elem interface{}) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
+func (q *Queue[T]) full() bool {
+	return q.capacity > 0 && q.count >= q.capacity
+}
+
+func (q *Queue[T]) add(elem T, front bool) {
 	if q.count == len(q.buf) {
 		q.resize()
 	}
-	q.buf[q.tail] = elem
-	q.tail = (q.tail + 1) % len(q.buf)
+	if front {
+		q.head = (q.head - 1 + len(q.buf)) % len(q.buf)
+		q.buf[q.head] = elem
+	} else {
+		q.buf[q.tail] = elem
+		q.tail = (q.tail + 1) % len(q.buf)
+	}
 	q.count++
+	broadcast(&q.notEmpty)
+}
+
+func (q *Queue[T]) pop() T {
+	var zero T
+	ret := q.buf[q.head]
+	q.buf[q.head] = zero // let the GC collect the popped element
+	q.head = (q.head + 1) % len(q.buf)
+	q.removed()
+	return ret
+}
+
+func (q *Queue[T]) popBack() T {
+	var zero T
+	q.tail = (q.tail - 1 + len(q.buf)) % len(q.buf)
+	ret := q.buf[q.tail]
+	q.buf[q.tail] = zero
+	q.removed()
+	return ret
+}
+
+// removed updates the count after an element has been taken out of buf.
+func (q *Queue[T]) removed() {
+	q.count--
+	if len(q.buf) > minQueueLen && q.count*4 <= len(q.buf) {
+		q.resize()
+	}
+	broadcast(&q.notFull)
+}
+
+// index converts a possibly negative logical index into a position in buf.
+// This call panics if i is out of range.
+func (q *Queue[T]) index(i int) int {
+	if i < 0 {
+		i += q.count
+	}
+	if i < 0 || i >= q.count {
+		panic("queue: index out of range")
+	}
+	return (q.head + i) % len(q.buf)
+}
+
+// Add puts an element on the end of the queue. If the queue is bounded
+// and full, Add blocks until there is room. This call panics if the queue
+// is closed.
+func (q *Queue[T]) Add(elem T) {
+	if err := q.addWait(context.Background(), elem, false); err != nil {
+		panic(err.Error())
+	}
+}
+
+// PushFront puts an element on the front of the queue. If the queue is
+// bounded and full, PushFront blocks until there is room. This call panics
+// if the queue is closed.
+func (q *Queue[T]) PushFront(elem T) {
+	if err := q.addWait(context.Background(), elem, true); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryAdd puts an element on the end of the queue without blocking.
+// It returns ErrFull if the queue is at capacity and ErrClosed if the queue
+// is closed.
+func (q *Queue[T]) TryAdd(elem T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.full() {
+		return ErrFull
+	}
+	q.add(elem, false)
+	return nil
+}
+
+// AddWait puts an element on the end of the queue, waiting for room if the
+// queue is bounded and full. It returns ErrClosed if the queue is closed,
+// or the context's error if ctx is done first.
+func (q *Queue[T]) AddWait(ctx context.Context, elem T) error {
+	return q.addWait(ctx, elem, false)
+}
+
+func (q *Queue[T]) addWait(ctx context.Context, elem T, front bool) error {
+	q.mu.Lock()
+	for !q.closed && q.full() {
+		ch := waiter(&q.notFull)
+		q.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	q.add(elem, front)
+	return nil
 }
 
 // Peek returns the element at the head of the queue. This call panics
 // if the queue is empty.
-func (q *Queue) Peek() interface{} {
+func (q *Queue[T]) Peek() T {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	if q.count <= 0 {
@@ -63,21 +254,178 @@ func (q *Queue) Peek() interface{} {
 	return q.buf[q.head]
 }
 
+// PeekBack returns the element at the end of the queue. This call panics
+// if the queue is empty.
+func (q *Queue[T]) PeekBack() T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.count <= 0 {
+		panic("queue: empty queue")
+	}
+	return q.buf[(q.tail-1+len(q.buf))%len(q.buf)]
+}
+
+// Get returns the element at index i in the queue. Index 0 is the head of
+// the queue and negative indexes count back from the end, so -1 is the last
+// element. This call panics if the index is out of range.
+func (q *Queue[T]) Get(i int) T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.buf[q.index(i)]
+}
+
 // Pop returns the element at the head of the queue and removes the element
 // from the front of the queue. If you actually want the element, call Peek
 // first. This call panics if the queue is empty.
-func (q *Queue) Pop() interface{} {
+func (q *Queue[T]) Pop() T {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	if q.count <= 0 {
 		panic("queue: empty queue")
 	}
-	ret := q.buf[q.head]
-	q.buf[q.head] = nil
-	q.head = (q.head + 1) % len(q.buf)
-	q.count--
-	if len(q.buf) > minQueueLen && q.count*4 <= len(q.buf) {
-		q.resize()
+	return q.pop()
+}
+
+// PopBack returns the element at the end of the queue and removes it.
+// This call panics if the queue is empty.
+func (q *Queue[T]) PopBack() T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.count <= 0 {
+		panic("queue: empty queue")
+	}
+	return q.popBack()
+}
+
+// Remove returns the element at index i in the queue and removes it,
+// accepting negative indexes like Get. This call panics if the index is
+// out of range.
+func (q *Queue[T]) Remove(i int) T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pos := q.index(i)
+	if i < 0 {
+		i += q.count
+	}
+	n := len(q.buf)
+	ret := q.buf[pos]
+	// close the gap by shifting whichever side of i is shorter
+	if i < q.count/2 {
+		for k := i; k > 0; k-- {
+			q.buf[(q.head+k)%n] = q.buf[(q.head+k-1)%n]
+		}
+		q.pop()
+	} else {
+		for k := i; k < q.count-1; k++ {
+			q.buf[(q.head+k)%n] = q.buf[(q.head+k+1)%n]
+		}
+		q.popBack()
 	}
 	return ret
 }
+
+// Clear removes all elements from the queue.
+func (q *Queue[T]) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.buf = make([]T, minQueueLen)
+	q.head, q.tail, q.count = 0, 0, 0
+	broadcast(&q.notFull)
+}
+
+// Slice returns a copy of the elements in the queue, from head to end.
+func (q *Queue[T]) Slice() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ret := make([]T, q.count)
+	for i := range ret {
+		ret[i] = q.buf[(q.head+i)%len(q.buf)]
+	}
+	return ret
+}
+
+// All returns an iterator over the indexes and elements of the queue, from
+// head to end. It iterates over a snapshot taken by Slice, so the queue can
+// be modified during the iteration.
+func (q *Queue[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i, elem := range q.Slice() {
+			if !yield(i, elem) {
+				return
+			}
+		}
+	}
+}
+
+// TryPop removes and returns the element at the head of the queue.
+// The second result is false if the queue is empty.
+func (q *Queue[T]) TryPop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.count <= 0 {
+		var zero T
+		return zero, false
+	}
+	return q.pop(), true
+}
+
+// PopN removes and returns up to n elements from the head of the queue
+// without blocking. The result is empty if the queue is empty.
+func (q *Queue[T]) PopN(n int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n > q.count {
+		n = q.count
+	}
+	if n <= 0 {
+		return nil
+	}
+	ret := make([]T, n)
+	for i := range ret {
+		ret[i] = q.pop()
+	}
+	return ret
+}
+
+// PopWait removes and returns the element at the head of the queue,
+// waiting until one is added if the queue is empty. It returns ErrClosed
+// once the queue is closed and drained, or the context's error if ctx is
+// done first.
+func (q *Queue[T]) PopWait(ctx context.Context) (T, error) {
+	var zero T
+	q.mu.Lock()
+	for q.count <= 0 {
+		if q.closed {
+			q.mu.Unlock()
+			return zero, ErrClosed
+		}
+		ch := waiter(&q.notEmpty)
+		q.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+	return q.pop(), nil
+}
+
+// waiter returns a channel that is closed by the next broadcast on ch.
+// The caller must hold the queue's lock.
+func waiter(ch *chan struct{}) chan struct{} {
+	if *ch == nil {
+		*ch = make(chan struct{})
+	}
+	return *ch
+}
+
+// broadcast wakes up everyone waiting on ch.
+// The caller must hold the queue's lock.
+func broadcast(ch *chan struct{}) {
+	if *ch != nil {
+		close(*ch)
+		*ch = nil
+	}
+}
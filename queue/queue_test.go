@@ -1,6 +1,10 @@
 package queue
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+)
 
 func TestQueueLength(t *testing.T) {
 	q := New()
@@ -53,6 +57,259 @@ func TestQueueRemoveOutOfRangePanics(t *testing.T) {
 	})
 }
 
+func TestTypedQueue(t *testing.T) {
+	q := NewOf[int]()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+	}
+	for i := 0; i < 1000; i++ {
+		if v := q.Pop(); v != i {
+			t.Error("popped", v, "want", i)
+		}
+	}
+	if len(q.buf) != minQueueLen {
+		t.Error("drained queue has buffer length", len(q.buf))
+	}
+}
+
+func TestTypedQueueZeroesPopped(t *testing.T) {
+	q := NewOf[*int]()
+
+	v := 1
+	q.Add(&v)
+	q.Pop()
+	for i, p := range q.buf {
+		if p != nil {
+			t.Error("slot", i, "still holds a popped element")
+		}
+	}
+}
+
+func TestQueueDeque(t *testing.T) {
+	q := NewOf[int]()
+
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+		q.PushFront(-i - 1)
+	}
+	if q.Length() != 200 {
+		t.Error("deque has length", q.Length())
+	}
+	if q.Peek() != -100 || q.PeekBack() != 99 {
+		t.Error("deque has ends", q.Peek(), q.PeekBack())
+	}
+	for i := 99; i >= 0; i-- {
+		if v := q.PopBack(); v != i {
+			t.Error("PopBack returned", v, "want", i)
+		}
+	}
+	for i := 100; i > 0; i-- {
+		if v := q.Pop(); v != -i {
+			t.Error("Pop returned", v, "want", -i)
+		}
+	}
+
+	assertPanics(t, "should panic when peeking back empty queue", func() {
+		q.PeekBack()
+	})
+	assertPanics(t, "should panic when removing back of empty queue", func() {
+		q.PopBack()
+	})
+}
+
+func TestQueueGet(t *testing.T) {
+	q := NewOf[int]()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(i)
+		for j := 0; j < q.Length(); j++ {
+			if q.Get(j) != j {
+				t.Fatal("index", j, "is", q.Get(j))
+			}
+			if q.Get(j-q.Length()) != j {
+				t.Fatal("index", j-q.Length(), "is", q.Get(j-q.Length()))
+			}
+		}
+	}
+
+	assertPanics(t, "should panic when indexing past the end", func() {
+		q.Get(1000)
+	})
+	assertPanics(t, "should panic when indexing before the head", func() {
+		q.Get(-1001)
+	})
+}
+
+func TestQueueRemove(t *testing.T) {
+	q := NewOf[int]()
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	if v := q.Remove(10); v != 10 {
+		t.Error("Remove returned", v)
+	}
+	if v := q.Remove(-10); v != 90 {
+		t.Error("Remove returned", v)
+	}
+	want := 0
+	for i, v := range q.All() {
+		if want == 10 || want == 90 {
+			want++
+		}
+		if v != want {
+			t.Error("index", i, "is", v, "want", want)
+		}
+		want++
+	}
+	if q.Length() != 98 {
+		t.Error("queue has length", q.Length())
+	}
+
+	assertPanics(t, "should panic when removing out of range", func() {
+		q.Remove(98)
+	})
+}
+
+func TestQueueClearSlice(t *testing.T) {
+	q := NewOf[int]()
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+	q.Pop()
+
+	s := q.Slice()
+	if len(s) != 99 || s[0] != 1 || s[98] != 99 {
+		t.Error("Slice returned", s)
+	}
+
+	q.Clear()
+	if q.Length() != 0 || len(q.Slice()) != 0 {
+		t.Error("Clear left", q.Length(), "elements")
+	}
+	q.Add(1)
+	if q.Pop() != 1 {
+		t.Error("queue is unusable after Clear")
+	}
+}
+
+func TestQueueTryPop(t *testing.T) {
+	q := New()
+
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop on empty queue succeeded")
+	}
+
+	q.Add(1)
+	v, ok := q.TryPop()
+	if !ok || v != 1 {
+		t.Error("TryPop returned", v, ok)
+	}
+}
+
+func TestQueuePopN(t *testing.T) {
+	q := New()
+	for i := 0; i < 100; i++ {
+		q.Add(i)
+	}
+
+	r := q.PopN(30)
+	if len(r) != 30 {
+		t.Fatal("PopN returned", len(r), "elements")
+	}
+	for i, v := range r {
+		if v != i {
+			t.Error("PopN element", i, "is", v)
+		}
+	}
+
+	r = q.PopN(100)
+	if len(r) != 70 || r[0] != 30 {
+		t.Error("PopN did not drain the rest of the queue")
+	}
+	if q.PopN(1) != nil {
+		t.Error("PopN on empty queue returned elements")
+	}
+}
+
+func TestQueuePopWait(t *testing.T) {
+	q := New()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Add(1)
+	}()
+
+	v, err := q.PopWait(context.Background())
+	if err != nil || v != 1 {
+		t.Error("PopWait returned", v, err)
+	}
+}
+
+func TestQueuePopWaitContext(t *testing.T) {
+	q := New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.PopWait(ctx); err != context.DeadlineExceeded {
+		t.Error("PopWait returned", err)
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	q := New()
+	q.Add(1)
+
+	done := make(chan error)
+	go func() {
+		q.PopWait(context.Background())
+		_, err := q.PopWait(context.Background())
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	if err := <-done; err != ErrClosed {
+		t.Error("PopWait on closed queue returned", err)
+	}
+	if err := q.TryAdd(2); err != ErrClosed {
+		t.Error("TryAdd on closed queue returned", err)
+	}
+	assertPanics(t, "should panic when adding to closed queue", func() {
+		q.Add(2)
+	})
+}
+
+func TestQueueBounded(t *testing.T) {
+	q := NewBounded(2)
+	q.Add(1)
+	q.Add(2)
+
+	if err := q.TryAdd(3); err != ErrFull {
+		t.Error("TryAdd on full queue returned", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.AddWait(ctx, 3); err != context.DeadlineExceeded {
+		t.Error("AddWait on full queue returned", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.Add(3)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Pop()
+	<-done
+	if q.Length() != 2 {
+		t.Error("bounded queue has length", q.Length())
+	}
+}
+
 func assertPanics(t *testing.T, name string, f func()) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -87,3 +344,46 @@ func BenchmarkQueueTickTock(b *testing.B) {
 		q.Pop()
 	}
 }
+
+// The Int benchmarks compare an untyped queue, which boxes every element,
+// with a typed one that stores them inline.
+
+func BenchmarkQueueSerialInt(b *testing.B) {
+	b.ReportAllocs()
+	q := New()
+	for i := 0; i < b.N; i++ {
+		q.Add(i + 1024)
+	}
+	for i := 0; i < b.N; i++ {
+		_ = q.Pop().(int)
+	}
+}
+
+func BenchmarkTypedQueueSerialInt(b *testing.B) {
+	b.ReportAllocs()
+	q := NewOf[int]()
+	for i := 0; i < b.N; i++ {
+		q.Add(i + 1024)
+	}
+	for i := 0; i < b.N; i++ {
+		_ = q.Pop()
+	}
+}
+
+func BenchmarkQueueTickTockInt(b *testing.B) {
+	b.ReportAllocs()
+	q := New()
+	for i := 0; i < b.N; i++ {
+		q.Add(i + 1024)
+		_ = q.Pop().(int)
+	}
+}
+
+func BenchmarkTypedQueueTickTockInt(b *testing.B) {
+	b.ReportAllocs()
+	q := NewOf[int]()
+	for i := 0; i < b.N; i++ {
+		q.Add(i + 1024)
+		_ = q.Pop()
+	}
+}
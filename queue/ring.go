@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"sync/atomic"
+)
+
+// cacheLinePad keeps the producer and consumer positions of a Ring on
+// separate cache lines.
+type cacheLinePad [64]byte
+
+type ringCell[T any] struct {
+	seq  atomic.Uint64
+	elem T
+}
+
+// Ring is a bounded lock-free multi-producer/multi-consumer queue based on
+// Dmitry Vyukov's algorithm. Unlike Queue it never takes a lock, which makes
+// it a better fit for paths with many concurrent producers and consumers,
+// but it has a fixed capacity and no blocking operations.
+type Ring[T any] struct {
+	_       cacheLinePad
+	enqueue atomic.Uint64
+	_       cacheLinePad
+	dequeue atomic.Uint64
+	_       cacheLinePad
+	mask    uint64
+	cells   []ringCell[T]
+}
+
+// NewRing constructs and returns a new Ring that holds at least capacity
+// elements. The capacity is rounded up to a power of two.
+func NewRing[T any](capacity int) *Ring[T] {
+	n := 2
+	for n < capacity {
+		n <<= 1
+	}
+	q := &Ring[T]{mask: uint64(n - 1), cells: make([]ringCell[T], n)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Capacity returns the maximum number of elements the ring holds.
+func (q *Ring[T]) Capacity() int {
+	return len(q.cells)
+}
+
+// Length returns the number of elements currently stored in the ring.
+// Under concurrent use the result is only an approximation.
+func (q *Ring[T]) Length() int {
+	n := int64(q.enqueue.Load() - q.dequeue.Load())
+	if n < 0 {
+		return 0
+	} else if n > int64(len(q.cells)) {
+		return len(q.cells)
+	}
+	return int(n)
+}
+
+// TryAdd puts an element on the end of the ring.
+// It returns ErrFull if the ring is at capacity.
+func (q *Ring[T]) TryAdd(elem T) error {
+	pos := q.enqueue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		diff := int64(cell.seq.Load() - pos)
+		if diff == 0 {
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				cell.elem = elem
+				cell.seq.Store(pos + 1)
+				return nil
+			}
+		} else if diff < 0 {
+			return ErrFull
+		}
+		pos = q.enqueue.Load()
+	}
+}
+
+// TryPop removes and returns the element at the head of the ring.
+// The second result is false if the ring is empty.
+func (q *Ring[T]) TryPop() (T, bool) {
+	var zero T
+	pos := q.dequeue.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		diff := int64(cell.seq.Load() - (pos + 1))
+		if diff == 0 {
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				elem := cell.elem
+				cell.elem = zero // let the GC collect the popped element
+				cell.seq.Store(pos + q.mask + 1)
+				return elem, true
+			}
+		} else if diff < 0 {
+			return zero, false
+		}
+		pos = q.dequeue.Load()
+	}
+}
@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	q := NewRing[int](5)
+	if q.Capacity() != 8 {
+		t.Error("ring has capacity", q.Capacity())
+	}
+
+	for i := 0; i < 8; i++ {
+		if err := q.TryAdd(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.TryAdd(8); err != ErrFull {
+		t.Error("TryAdd on full ring returned", err)
+	}
+	if q.Length() != 8 {
+		t.Error("ring has length", q.Length())
+	}
+
+	for i := 0; i < 8; i++ {
+		if v, ok := q.TryPop(); !ok || v != i {
+			t.Error("TryPop returned", v, ok)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop on empty ring succeeded")
+	}
+}
+
+func TestRingConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 8, 8, 2000
+	q := NewRing[int](64)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				for q.TryAdd(p*perProducer+i) != nil {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	results := make(chan []int, consumers)
+	var remaining sync.WaitGroup
+	remaining.Add(producers * perProducer)
+	done := make(chan struct{})
+	for c := 0; c < consumers; c++ {
+		go func() {
+			var got []int
+			for {
+				if v, ok := q.TryPop(); ok {
+					got = append(got, v)
+					remaining.Done()
+					continue
+				}
+				select {
+				case <-done:
+					results <- got
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	remaining.Wait()
+	close(done)
+
+	seen := make([]bool, producers*perProducer)
+	for c := 0; c < consumers; c++ {
+		for _, v := range <-results {
+			if seen[v] {
+				t.Fatal("element", v, "popped twice")
+			}
+			seen[v] = true
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatal("element", v, "never popped")
+		}
+	}
+}
+
+// benchmarkContention runs b.N add/pop pairs spread over n goroutines.
+func benchmarkContention(b *testing.B, q Interface[int], n int) {
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for g := 0; g < n; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < b.N/n; i++ {
+				for q.TryAdd(i) != nil {
+					runtime.Gosched()
+				}
+				for {
+					if _, ok := q.TryPop(); ok {
+						break
+					}
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkContention(b *testing.B) {
+	for _, n := range []int{1, 2, 4, 8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("Queue/%d", n), func(b *testing.B) {
+			benchmarkContention(b, NewOf[int](), n)
+		})
+		b.Run(fmt.Sprintf("Ring/%d", n), func(b *testing.B) {
+			benchmarkContention(b, NewRing[int](1024), n)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/najeira/goutils/nlog"
+)
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Execer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Query runs a query, logging it with the logger of ctx: failures at Error
+// and others at Debug, with the time taken.
+func Query(ctx context.Context, db Queryer, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, time.Since(start), err)
+	return rows, err
+}
+
+// Exec runs a statement, logging it as Query does.
+func Exec(ctx context.Context, db Execer, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, time.Since(start), err)
+	return res, err
+}
+
+// QueryMaps runs a query as Query does and reads all of its rows as
+// RowsToMapsContext does.
+func QueryMaps(ctx context.Context, db Queryer, newRow func() Row, query string, args ...interface{}) ([]Row, error) {
+	sqlRows, err := Query(ctx, db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlRows.Close()
+	return RowsToMapsContext(ctx, sqlRows, newRow)
+}
+
+func logQuery(ctx context.Context, query string, took time.Duration, err error) {
+	l := nlog.FromContext(ctx)
+	if err != nil {
+		l.Error("sqlutil: query failed", "query", query, "took", took, "err", err)
+	} else if l.V(nlog.Debug) {
+		l.Debug("sqlutil: query", "query", query, "took", took)
+	}
+}
@@ -0,0 +1,199 @@
+package sqlutil
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/najeira/goutils/nlog"
+	"github.com/najeira/goutils/nlog/nlogtest"
+)
+
+// fakeDriver returns the rows of names for any query, failing after them
+// if err is set.
+type fakeDriver struct {
+	names []string
+	err   error
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{names: c.d.names, err: c.d.err}, nil
+}
+
+type fakeRows struct {
+	names []string
+	err   error
+	i     int
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"name"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i == len(r.names) {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	dest[0] = r.names[r.i]
+	r.i++
+	return nil
+}
+
+type fakeConnector struct {
+	d *fakeDriver
+}
+
+func (c fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.d.Open("")
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return c.d
+}
+
+func openFake(names []string, err error) *sql.DB {
+	return sql.OpenDB(fakeConnector{&fakeDriver{names: names, err: err}})
+}
+
+// errQueryer is a Queryer that fails.
+type errQueryer struct{}
+
+func (errQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("connection refused")
+}
+
+func newRow() Row {
+	return Row{"name": &NullString{}}
+}
+
+func requestContext(t *testing.T) (context.Context, *nlogtest.Logger) {
+	l := nlogtest.New(t)
+	return nlog.NewContext(context.Background(), l.With("request_id", "req-1")), l
+}
+
+func assertRequestID(t *testing.T, l *nlogtest.Logger, level int, msg string) {
+	t.Helper()
+	l.AssertLogged(t, level, msg)
+	for _, e := range l.Entries() {
+		if e.Message != msg {
+			continue
+		}
+		if id, _ := e.Field("request_id"); id != "req-1" {
+			t.Errorf("%s: request_id %v != req-1", msg, id)
+		}
+	}
+}
+
+func TestQueryMaps(t *testing.T) {
+	ctx, l := requestContext(t)
+	db := openFake([]string{"a", "b"}, nil)
+	defer db.Close()
+
+	rows, err := QueryMaps(ctx, db, newRow, "SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d != 2", len(rows))
+	}
+	if s, _ := rows[1].String("name"); s.String != "b" {
+		t.Errorf("%s != b", s.String)
+	}
+	assertRequestID(t, l, nlog.Debug, "sqlutil: query")
+	if e, _ := l.Entries()[0].Field("query"); e != "SELECT name FROM users" {
+		t.Errorf("%v", e)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	ctx, l := requestContext(t)
+	if _, err := Query(ctx, errQueryer{}, "SELECT 1"); err == nil {
+		t.Error("no error")
+	}
+	assertRequestID(t, l, nlog.Error, "sqlutil: query failed")
+}
+
+func TestRowsToMapsContext(t *testing.T) {
+	ctx, l := requestContext(t)
+	db := openFake([]string{"a"}, errors.New("connection reset"))
+	defer db.Close()
+
+	sqlRows, err := db.QueryContext(ctx, "SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlRows.Close()
+	if _, err := RowsToMapsContext(ctx, sqlRows, newRow); err == nil {
+		t.Error("no error")
+	}
+	assertRequestID(t, l, nlog.Error, "sqlutil: reading rows failed")
+
+	l.Reset()
+	sqlRows, err = db.QueryContext(ctx, "SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlRows.Close()
+	rows, err := NewRowsContext(ctx, sqlRows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Next()
+	// the column is not a number
+	if err := rows.Scan(Row{"name": &NullInt64{}}); err == nil {
+		t.Error("no error")
+	}
+	assertRequestID(t, l, nlog.Error, "sqlutil: scan failed")
+}
+
+func TestRowsToMapsNoLogger(t *testing.T) {
+	var buf bytes.Buffer
+	nlog.DefaultCore().SetOutput(&buf)
+	defer nlog.DefaultCore().SetOutput(os.Stdout)
+
+	db := openFake([]string{"a"}, errors.New("connection reset"))
+	defer db.Close()
+	sqlRows, err := db.Query("SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlRows.Close()
+	if _, err := RowsToMaps(sqlRows, newRow); err == nil {
+		t.Error("no error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("logged %q", buf.String())
+	}
+}
@@ -1,12 +1,15 @@
 package sqlutil
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/najeira/goutils/nlog"
 )
 
 var ErrNotFound = errors.New("not found")
@@ -302,6 +305,7 @@ func (r Row) Bytes(name string) ([]byte, error) {
 
 type Rows struct {
 	*sql.Rows
+	ctx      context.Context
 	columns  []string
 	scanners []interface{}
 	row      Row
@@ -321,11 +325,19 @@ func (s *scanner) Scan(src interface{}) error {
 }
 
 func NewRows(sqlRows *sql.Rows) (*Rows, error) {
+	return NewRowsContext(context.Background(), sqlRows)
+}
+
+// NewRowsContext is like NewRows, but failures are also logged with the
+// logger of ctx, if it carries one, so that they have its fields such as
+// the request ID.
+func NewRowsContext(ctx context.Context, sqlRows *sql.Rows) (*Rows, error) {
 	columns, err := sqlRows.Columns()
 	if err != nil {
+		logError(ctx, "sqlutil: columns failed", err)
 		return nil, err
 	}
-	r := &Rows{sqlRows, nil, nil, nil}
+	r := &Rows{Rows: sqlRows, ctx: ctx}
 	scanners := make([]interface{}, len(columns))
 	for i := range scanners {
 		scanners[i] = &scanner{rows: r, column: columns[i]}
@@ -342,11 +354,22 @@ func (r *Rows) Scan(row Row) error {
 	r.row = row
 	err := r.Rows.Scan(r.scanners...)
 	r.row = nil
+	if err != nil {
+		logError(r.ctx, "sqlutil: scan failed", err)
+	}
 	return err
 }
 
+// RowsToMaps scans every row into a Row made by newRow. It fails with the
+// error of sqlRows.Err if reading the rows stopped early.
 func RowsToMaps(sqlRows *sql.Rows, newRow func() Row) ([]Row, error) {
-	rows, err := NewRows(sqlRows)
+	return RowsToMapsContext(context.Background(), sqlRows, newRow)
+}
+
+// RowsToMapsContext is like RowsToMaps, but logs with the logger of ctx as
+// NewRowsContext does.
+func RowsToMapsContext(ctx context.Context, sqlRows *sql.Rows, newRow func() Row) ([]Row, error) {
+	rows, err := NewRowsContext(ctx, sqlRows)
 	if err != nil {
 		return nil, err
 	}
@@ -359,5 +382,17 @@ func RowsToMaps(sqlRows *sql.Rows, newRow func() Row) ([]Row, error) {
 		}
 		rets = append(rets, row)
 	}
+	if err := rows.Err(); err != nil {
+		logError(ctx, "sqlutil: reading rows failed", err)
+		return nil, err
+	}
 	return rets, nil
 }
+
+// logError logs err with the logger of ctx. The helpers return their errors,
+// so nothing is logged if ctx carries no logger.
+func logError(ctx context.Context, msg string, err error) {
+	if l, ok := nlog.LookupContext(ctx); ok {
+		l.Error(msg, "err", err)
+	}
+}